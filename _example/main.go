@@ -13,16 +13,6 @@ import (
 
 var pool = logger.NewBufferPool(16)
 
-func logged(handler http.HandlerFunc) http.HandlerFunc {
-	return func(res http.ResponseWriter, req *http.Request) {
-		start := time.Now()
-		logger.Default().OnEvent(logger.EventWebRequestStart, req)
-		rw := logger.NewResponseWriter(res)
-		handler(rw, req)
-		logger.Default().OnEvent(logger.EventWebRequest, req, rw.StatusCode(), rw.ContentLength(), time.Now().Sub(start))
-	}
-}
-
 func stdoutLogged(handler http.HandlerFunc) http.HandlerFunc {
 	return func(res http.ResponseWriter, req *http.Request) {
 		start := time.Now()
@@ -69,7 +59,6 @@ func postHandler(res http.ResponseWriter, req *http.Request) {
 	defer pool.Put(b)
 	b.ReadFrom(req.Body)
 	res.Write([]byte(fmt.Sprintf(`{"status":"ok!","received_bytes":%d}`, b.Len())))
-	logger.Default().OnEvent(logger.EventWebRequestPostBody, b.Bytes())
 }
 
 func port() string {
@@ -93,8 +82,8 @@ func main() {
 			logger.WriteRequest(writer, ts, req, statusCode, contentLengthBytes, elapsed)
 		}))
 	logger.Default().AddEventListener(logger.EventWebRequestPostBody,
-		logger.NewRequestBodyListener(func(writer logger.Logger, ts logger.TimeSource, body []byte) {
-			logger.WriteRequestBody(writer, ts, body)
+		logger.NewRequestBodyListener(func(writer logger.Logger, ts logger.TimeSource, body []byte, fields logger.Fields) {
+			logger.WriteRequestBody(writer, ts, body, fields...) // fields carry the request id
 		}))
 	logger.Default().AddEventListener(logger.EventError, func(wr logger.Logger, ts logger.TimeSource, e logger.EventFlag, args ...interface{}) {
 		//ping an external service?
@@ -102,14 +91,17 @@ func main() {
 		//this action will be handled by a separate go-routine
 	})
 
-	http.HandleFunc("/", logged(indexHandler))
+	logged := logger.NewMiddleware(logger.Default())
+	logged.SetCaptureRequestBody(4096)
+
+	http.HandleFunc("/", logged.HandlerFunc(indexHandler))
 
-	http.HandleFunc("/fatalerror", logged(fatalErrorHandler))
-	http.HandleFunc("/error", logged(errorHandler))
-	http.HandleFunc("/warning", logged(warningHandler))
-	http.HandleFunc("/post", logged(postHandler))
+	http.HandleFunc("/fatalerror", logged.HandlerFunc(fatalErrorHandler))
+	http.HandleFunc("/error", logged.HandlerFunc(errorHandler))
+	http.HandleFunc("/warning", logged.HandlerFunc(warningHandler))
+	http.HandleFunc("/post", logged.HandlerFunc(postHandler))
 
-	http.HandleFunc("/bench/logged", logged(indexHandler))
+	http.HandleFunc("/bench/logged", logged.HandlerFunc(indexHandler))
 	http.HandleFunc("/bench/stdout", stdoutLogged(indexHandler))
 
 	logger.Default().Infof("Listening on :%s", port())
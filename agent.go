@@ -1,10 +1,12 @@
 package logger
 
 import (
-	"fmt"
+	"context"
 	"net/http"
 	"os"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/blendlabs/go-workqueue"
@@ -45,8 +47,7 @@ func New(events *EventFlagSet) *Agent {
 	return &Agent{
 		events:         events,
 		eventQueue:     newEventQueue(),
-		eventListeners: map[EventFlag][]EventListener{},
-		debugListeners: []EventListener{},
+		eventListeners: map[EventFlag][]registeredListener{},
 		writer:         NewWriterWithError(os.Stdout, os.Stderr),
 	}
 }
@@ -56,15 +57,28 @@ func NewWithWriter(events *EventFlagSet, writer *Writer) *Agent {
 	return &Agent{
 		events:         events,
 		eventQueue:     newEventQueue(),
-		eventListeners: map[EventFlag][]EventListener{},
-		debugListeners: []EventListener{},
+		eventListeners: map[EventFlag][]registeredListener{},
 		writer:         writer,
 	}
 }
 
 // NewFromEnvironment returns a new diagnostics with a given bitflag verbosity.
 func NewFromEnvironment() *Agent {
-	return NewWithWriter(NewEventFlagSetFromEnvironment(), NewWriterFromEnvironment())
+	agent := NewWithWriter(NewEventFlagSetFromEnvironment(), NewWriterFromEnvironment())
+	if policy := os.Getenv(EnvironmentVariableLogQueueOverflow); len(policy) > 0 {
+		agent.SetQueueOverflowPolicy(ParseQueueOverflowPolicy(policy), envFlagDuration(EnvironmentVariableLogQueueOverflowTimeout, DefaultQueueOverflowTimeout))
+	}
+	agent.SetSnapshotArgs(envFlagIsSet(EnvironmentVariableLogSnapshotArgs, false))
+	agent.SetOrderedWrites(envFlagIsSet(EnvironmentVariableLogOrderedWrites, false))
+	if timeout := envFlagDuration(EnvironmentVariableLogListenerTimeout, 0); timeout > 0 {
+		agent.SetListenerTimeout(timeout)
+	}
+	if spillFile := os.Getenv(EnvironmentVariableLogQueueSpillFile); len(spillFile) > 0 {
+		if err := agent.EnableSpillover(spillFile); err != nil {
+			panic(err)
+		}
+	}
+	return agent
 }
 
 // All returns a valid agent that fires all events.
@@ -85,13 +99,27 @@ func None(writer ...*Writer) *Agent {
 
 // Agent is a handler for various logging events with descendent handlers.
 type Agent struct {
+	// listenerTimeout is accessed atomically, and is first to keep it 64 bit aligned.
+	listenerTimeout int64
+	snapshotArgs    int32
+
 	writer             *Writer
 	eventsLock         sync.Mutex
 	events             *EventFlagSet
 	eventListenersLock sync.Mutex
-	eventListeners     map[EventFlag][]EventListener
-	debugListeners     []EventListener
+	eventListeners     map[EventFlag][]registeredListener
+	subtreeListeners   map[EventFlag][]registeredListener
+	debugListeners     []registeredListener
+	lastListenerID     ListenerID
+	eventsSnapshot     atomic.Value
+	listenerSnapshot   atomic.Value
 	eventQueue         *workqueue.Queue
+
+	queueLock          sync.Mutex
+	overflowQueue      *overflowQueue
+	overflowReportStop chan struct{}
+	orderedWrites      bool
+	inFlight           inFlight
 }
 
 // Writer returns the inner Logger for the diagnostics agent.
@@ -104,7 +132,8 @@ func (da *Agent) EventQueue() *workqueue.Queue {
 	return da.eventQueue
 }
 
-// Events returns the EventFlagSet
+// Events returns the EventFlagSet.
+// Change it through the agent (`EnableEvent`, `SetVerbosity` etc.), as changes made to it directly aren't seen by `IsEnabled`.
 func (da *Agent) Events() *EventFlagSet {
 	if da == nil {
 		return nil
@@ -116,6 +145,15 @@ func (da *Agent) Events() *EventFlagSet {
 func (da *Agent) SetVerbosity(events *EventFlagSet) {
 	da.eventsLock.Lock()
 	da.events = events
+	da.publishEvents()
+	da.eventsLock.Unlock()
+}
+
+// SetMinSeverity enables every event at or above a given severity, on top of the explicitly enabled events.
+func (da *Agent) SetMinSeverity(severity Severity) {
+	da.eventsLock.Lock()
+	da.events.SetMinSeverity(severity)
+	da.publishEvents()
 	da.eventsLock.Unlock()
 }
 
@@ -123,6 +161,7 @@ func (da *Agent) SetVerbosity(events *EventFlagSet) {
 func (da *Agent) EnableEvent(eventFlag EventFlag) {
 	da.eventsLock.Lock()
 	da.events.Enable(eventFlag)
+	da.publishEvents()
 	da.eventsLock.Unlock()
 }
 
@@ -130,18 +169,17 @@ func (da *Agent) EnableEvent(eventFlag EventFlag) {
 func (da *Agent) DisableEvent(eventFlag EventFlag) {
 	da.eventsLock.Lock()
 	da.events.Disable(eventFlag)
+	da.publishEvents()
 	da.eventsLock.Unlock()
 }
 
 // IsEnabled asserts if a flag value is set or not.
+// It reads a snapshot of the agent's events, so it doesn't take a lock.
 func (da *Agent) IsEnabled(flagValue EventFlag) bool {
 	if da == nil {
 		return false
 	}
-	da.eventsLock.Lock()
-	enabled := da.events.IsEnabled(flagValue)
-	da.eventsLock.Unlock()
-	return enabled
+	return da.enabledEvents().IsEnabled(flagValue)
 }
 
 // HasListener returns if there are registered listener for an event.
@@ -149,33 +187,115 @@ func (da *Agent) HasListener(event EventFlag) bool {
 	if da == nil {
 		return false
 	}
-	if da.eventListeners == nil {
-		return false
-	}
-	listeners, hasHandler := da.eventListeners[event]
-	if !hasHandler {
-		return false
-	}
-	return len(listeners) > 0
+	return da.listeners().HasListener(event)
 }
 
-// AddEventListener adds a listener for errors.
-func (da *Agent) AddEventListener(eventFlag EventFlag, listener EventListener) {
+// AddEventListener adds a listener for an event, returning an id that can be used to remove it.
+// A wildcard flag (`web.*`) adds a listener for every event below it (`web.request`, `web.request.start` etc.).
+func (da *Agent) AddEventListener(eventFlag EventFlag, listener EventListener) ListenerID {
+	return da.AddNamedEventListener(eventFlag, "", listener)
+}
+
+// AddNamedEventListener adds a listener for an event with a name, which is shown by `Listeners()` and in listener errors.
+func (da *Agent) AddNamedEventListener(eventFlag EventFlag, name string, listener EventListener) ListenerID {
 	da.eventListenersLock.Lock()
-	da.eventListeners[eventFlag] = append(da.eventListeners[eventFlag], listener)
+	registered := da.registerListener(eventFlag, name, listener)
+	if subtree, isWildcard := eventFlag.Subtree(); isWildcard {
+		if da.subtreeListeners == nil {
+			da.subtreeListeners = map[EventFlag][]registeredListener{}
+		}
+		da.subtreeListeners[subtree] = append(da.subtreeListeners[subtree], registered)
+	} else {
+		if da.eventListeners == nil {
+			da.eventListeners = map[EventFlag][]registeredListener{}
+		}
+		da.eventListeners[eventFlag] = append(da.eventListeners[eventFlag], registered)
+	}
+	da.publishListeners()
 	da.eventListenersLock.Unlock()
+	return registered.ID
 }
 
-// AddDebugListener adds a listener that will fire on *all* events.
-func (da *Agent) AddDebugListener(listener EventListener) {
+// AddDebugListener adds a listener that will fire on *all* events, returning an id that can be used to remove it.
+func (da *Agent) AddDebugListener(listener EventListener) ListenerID {
+	return da.AddNamedDebugListener("", listener)
+}
+
+// AddNamedDebugListener adds a listener that will fire on *all* events with a name.
+func (da *Agent) AddNamedDebugListener(name string, listener EventListener) ListenerID {
 	da.eventListenersLock.Lock()
-	da.debugListeners = append(da.debugListeners, listener)
+	registered := da.registerListener(EventAll, name, listener)
+	da.debugListeners = append(da.debugListeners, registered)
+	da.publishListeners()
 	da.eventListenersLock.Unlock()
+	return registered.ID
+}
+
+// Listeners returns the registered listeners, in the order they were added.
+func (da *Agent) Listeners() []ListenerInfo {
+	da.eventListenersLock.Lock()
+	defer da.eventListenersLock.Unlock()
+
+	var listeners []ListenerInfo
+	for _, eventListeners := range da.eventListeners {
+		for _, registered := range eventListeners {
+			listeners = append(listeners, registered.ListenerInfo)
+		}
+	}
+	for _, subtreeListeners := range da.subtreeListeners {
+		for _, registered := range subtreeListeners {
+			listeners = append(listeners, registered.ListenerInfo)
+		}
+	}
+	for _, registered := range da.debugListeners {
+		listeners = append(listeners, registered.ListenerInfo)
+	}
+	sort.Slice(listeners, func(i, j int) bool {
+		return listeners[i].ID < listeners[j].ID
+	})
+	return listeners
+}
+
+// RemoveListener removes a listener by the id it was added with, returning if it was found.
+func (da *Agent) RemoveListener(id ListenerID) bool {
+	da.eventListenersLock.Lock()
+	defer da.eventListenersLock.Unlock()
+
+	if removeListenerFrom(da.eventListeners, id) || removeListenerFrom(da.subtreeListeners, id) {
+		da.publishListeners()
+		return true
+	}
+	if remaining, removed := withoutListener(da.debugListeners, id); removed {
+		da.debugListeners = remaining
+		da.publishListeners()
+		return true
+	}
+	return false
+}
+
+// SetListenerTimeout sets how long a listener can run before the agent stops waiting on it and reports an `EventListenerError`.
+// A timed out listener keeps running on its own goroutine, so it doesn't hold up the other listeners.
+// Zero (the default) waits on listeners indefinitely.
+func (da *Agent) SetListenerTimeout(timeout time.Duration) {
+	atomic.StoreInt64(&da.listenerTimeout, int64(timeout))
+}
+
+// ListenerTimeout returns the listener timeout.
+func (da *Agent) ListenerTimeout() time.Duration {
+	return time.Duration(atomic.LoadInt64(&da.listenerTimeout))
 }
 
 // RemoveListeners clears *all* listeners for an EventFlag.
+// A wildcard flag (`web.*`) clears the listeners added with that wildcard, not those added for the events below it.
 func (da *Agent) RemoveListeners(eventFlag EventFlag) {
-	delete(da.eventListeners, eventFlag)
+	da.eventListenersLock.Lock()
+	if subtree, isWildcard := eventFlag.Subtree(); isWildcard {
+		delete(da.subtreeListeners, subtree)
+	} else {
+		delete(da.eventListeners, eventFlag)
+	}
+	da.publishListeners()
+	da.eventListenersLock.Unlock()
 }
 
 // OnEvent fires the currently configured event listeners.
@@ -183,8 +303,8 @@ func (da *Agent) OnEvent(eventFlag EventFlag, state ...interface{}) {
 	if da == nil {
 		return
 	}
-	if da.IsEnabled(eventFlag) && da.HasListener(eventFlag) {
-		da.eventQueue.Enqueue(da.triggerListeners, append([]interface{}{TimeNow(), eventFlag}, state...)...)
+	if da.IsEnabled(eventFlag) {
+		da.onEvent(eventFlag, state...)
 	}
 }
 
@@ -204,12 +324,31 @@ func (da *Agent) Debugf(format string, args ...interface{}) {
 	da.WriteEventf(EventDebug, ColorLightYellow, format, args...)
 }
 
+// InfoWithFields logs an informational message with structured fields to the output stream.
+func (da *Agent) InfoWithFields(fields Fields, format string, args ...interface{}) {
+	if da == nil {
+		return
+	}
+	da.WriteEventWithFields(EventInfo, ColorLightWhite, fields, format, args...)
+}
+
+// DebugWithFields logs a debug message with structured fields to the output stream.
+func (da *Agent) DebugWithFields(fields Fields, format string, args ...interface{}) {
+	if da == nil {
+		return
+	}
+	da.WriteEventWithFields(EventDebug, ColorLightYellow, fields, format, args...)
+}
+
 // Warningf logs a debug message to the output stream.
 func (da *Agent) Warningf(format string, args ...interface{}) error {
 	if da == nil {
 		return nil
 	}
-	return da.Warning(fmt.Errorf(format, args...))
+	if da.IsEnabled(EventWarning) {
+		args = da.captureArgs(args)
+	}
+	return da.Warning(newLazyError(format, args...))
 }
 
 // Warning logs a warning error to std err.
@@ -220,12 +359,20 @@ func (da *Agent) Warning(err error) error {
 	return da.ErrorEventWithState(EventWarning, ColorLightYellow, err)
 }
 
-// WarningWithReq logs a warning error to std err with a request.
+// WarningWithReq logs a warning error to std err with a request, and its request id if it has one.
 func (da *Agent) WarningWithReq(err error, req *http.Request) error {
 	if da == nil {
 		return err
 	}
-	return da.ErrorEventWithState(EventWarning, ColorLightYellow, err, req)
+	return da.ErrorEventWithFields(EventWarning, ColorLightYellow, err, requestIDFields(req), req)
+}
+
+// WarningWithFields logs a warning error with structured fields to std err.
+func (da *Agent) WarningWithFields(err error, fields Fields) error {
+	if da == nil {
+		return err
+	}
+	return da.ErrorEventWithFields(EventWarning, ColorLightYellow, err, fields)
 }
 
 // Errorf writes an event to the log and triggers event listeners.
@@ -233,7 +380,10 @@ func (da *Agent) Errorf(format string, args ...interface{}) error {
 	if da == nil {
 		return nil
 	}
-	return da.Error(fmt.Errorf(format, args...))
+	if da.IsEnabled(EventError) {
+		args = da.captureArgs(args)
+	}
+	return da.Error(newLazyError(format, args...))
 }
 
 // Error logs an error to std err.
@@ -244,12 +394,20 @@ func (da *Agent) Error(err error) error {
 	return da.ErrorEventWithState(EventError, ColorRed, err)
 }
 
-// ErrorWithReq logs an error to std err with a request.
+// ErrorWithReq logs an error to std err with a request, and its request id if it has one.
 func (da *Agent) ErrorWithReq(err error, req *http.Request) error {
 	if da == nil {
 		return err
 	}
-	return da.ErrorEventWithState(EventError, ColorRed, err, req)
+	return da.ErrorEventWithFields(EventError, ColorRed, err, requestIDFields(req), req)
+}
+
+// ErrorWithFields logs an error with structured fields to std err.
+func (da *Agent) ErrorWithFields(err error, fields Fields) error {
+	if da == nil {
+		return err
+	}
+	return da.ErrorEventWithFields(EventError, ColorRed, err, fields)
 }
 
 // Fatalf writes an event to the log and triggers event listeners.
@@ -257,7 +415,10 @@ func (da *Agent) Fatalf(format string, args ...interface{}) error {
 	if da == nil {
 		return nil
 	}
-	return da.Fatal(fmt.Errorf(format, args...))
+	if da.IsEnabled(EventFatalError) {
+		args = da.captureArgs(args)
+	}
+	return da.Fatal(newLazyError(format, args...))
 }
 
 // Fatal logs the result of a panic to std err.
@@ -268,12 +429,20 @@ func (da *Agent) Fatal(err error) error {
 	return da.ErrorEventWithState(EventFatalError, ColorRed, err)
 }
 
-// FatalWithReq logs the result of a fatal error to std err with a request.
+// FatalWithReq logs the result of a fatal error to std err with a request, and its request id if it has one.
 func (da *Agent) FatalWithReq(err error, req *http.Request) error {
 	if da == nil {
 		return err
 	}
-	return da.ErrorEventWithState(EventFatalError, ColorRed, err, req)
+	return da.ErrorEventWithFields(EventFatalError, ColorRed, err, requestIDFields(req), req)
+}
+
+// FatalWithFields logs the result of a fatal error with structured fields to std err.
+func (da *Agent) FatalWithFields(err error, fields Fields) error {
+	if da == nil {
+		return err
+	}
+	return da.ErrorEventWithFields(EventFatalError, ColorRed, err, fields)
 }
 
 // --------------------------------------------------------------------------------
@@ -285,12 +454,17 @@ func (da *Agent) WriteEventf(event EventFlag, color AnsiColorCode, format string
 	if da == nil {
 		return
 	}
-	if da.IsEnabled(event) {
-		da.queueWrite(event, ColorLightYellow, format, args...)
+	da.WriteEventWithFields(event, color, nil, format, args...)
+}
 
-		if da.HasListener(event) {
-			da.eventQueue.Enqueue(da.triggerListeners, append([]interface{}{TimeNow(), event, format}, args...)...)
-		}
+// WriteEventWithFields writes to the standard output with structured fields and triggers events.
+// Listeners receive the format and args as state, followed by the fields if any are set.
+func (da *Agent) WriteEventWithFields(event EventFlag, color AnsiColorCode, fields Fields, format string, args ...interface{}) {
+	if da == nil {
+		return
+	}
+	if da.IsEnabled(event) {
+		da.writeEventWithFields(event, color, fields, format, args...)
 	}
 }
 
@@ -300,10 +474,11 @@ func (da *Agent) WriteErrorEventf(event EventFlag, color AnsiColorCode, format s
 		return
 	}
 	if da.IsEnabled(event) {
-		da.queueWriteError(event, ColorLightYellow, format, args...)
+		args = da.captureArgs(args)
+		da.queueWriteError(event, color, nil, format, args...)
 
 		if da.HasListener(event) {
-			da.eventQueue.Enqueue(da.triggerListeners, append([]interface{}{TimeNow(), event, format}, args...)...)
+			da.enqueue(event, da.triggerListeners, append([]interface{}{TimeNow(), event, format}, args...)...)
 		}
 	}
 }
@@ -313,15 +488,155 @@ func (da *Agent) ErrorEventWithState(event EventFlag, color AnsiColorCode, err e
 	if da == nil {
 		return err
 	}
+	return da.ErrorEventWithFields(event, color, err, nil, state...)
+}
+
+// ErrorEventWithFields writes an error with structured fields and triggers events with a given state.
+// Listeners receive the error and state, followed by the fields if any are set.
+func (da *Agent) ErrorEventWithFields(event EventFlag, color AnsiColorCode, err error, fields Fields, state ...interface{}) error {
+	if da == nil {
+		return err
+	}
+	if err != nil && da.IsEnabled(event) {
+		da.errorEventWithFields(event, color, err, fields, state...)
+	}
+	return err
+}
+
+// SetQueueOverflowPolicy sets what the agent does with events when its queue is full.
+// Policies other than `QueueOverflowBlock` count the events they drop, and fire `EventQueueOverflow` periodically when events have been dropped
+// (unless the agent's events disable it explicitly). The timeout is only used by `QueueOverflowBlockWithTimeout`.
+// It should be set before the agent is in use.
+func (da *Agent) SetQueueOverflowPolicy(policy QueueOverflowPolicy, timeout time.Duration) {
+	if policy == QueueOverflowBlock || len(policy) == 0 {
+		da.setOverflowQueue(nil)
+		return
+	}
+	da.setOverflowQueue(newOverflowQueue(da.eventQueue, policy, timeout))
+}
+
+// EnableSpillover sets the queue overflow policy to `QueueOverflowSpill`, spilling writes that don't fit in the queue to a file at a given path.
+// Records already in the file (left by a previous process) are written first, with their original timestamps.
+// Spilled writes are replayed once the queue drains, and writes still queued when the agent is closed are spilled.
+// Records in the file that can't be read are moved to `<path>.corrupt`, and reported as a warning.
+func (da *Agent) EnableSpillover(filePath string) error {
+	spill, err := openSpillFile(filePath)
 	if err != nil {
-		if da.IsEnabled(event) {
-			da.queueWriteError(event, color, "%+v", err)
-			if da.HasListener(event) {
-				da.eventQueue.Enqueue(da.triggerListeners, append([]interface{}{TimeNow(), event, err}, state...)...)
-			}
+		return err
+	}
+	_, err = spill.Replay(da.replaySpilled)
+	if corruption, isCorruption := err.(*SpillCorruptionError); isCorruption {
+		da.Sync().Warning(corruption)
+	} else if err != nil {
+		spill.Close()
+		return err
+	}
+
+	overflowQueue := newOverflowQueue(da.eventQueue, QueueOverflowSpill, 0)
+	overflowQueue.spill = spill
+	overflowQueue.spillReplay = da.replaySpilled
+	da.setOverflowQueue(overflowQueue)
+	return nil
+}
+
+// SetOrderedWrites sets if the agent writes events one at a time, in the order they were logged.
+// By default the queue's workers write events in parallel, so events logged one after another can be written out of order.
+// Ordered writes still share the queue (and its overflow policy) with listener triggers, which keep running in parallel.
+// It should be set before the agent is in use.
+func (da *Agent) SetOrderedWrites(orderedWrites bool) {
+	da.queueLock.Lock()
+	da.orderedWrites = orderedWrites
+	overflowQueue := da.overflowQueue
+	da.queueLock.Unlock()
+
+	switch {
+	case overflowQueue == nil:
+		if orderedWrites {
+			da.setOverflowQueue(nil)
 		}
+	case !orderedWrites && overflowQueue.policy == QueueOverflowBlock:
+		da.setOverflowQueue(nil)
+	default:
+		overflowQueue.SetOrdered(orderedWrites)
 	}
-	return err
+}
+
+// OrderedWrites returns if the agent writes events in the order they were logged.
+func (da *Agent) OrderedWrites() bool {
+	da.queueLock.Lock()
+	defer da.queueLock.Unlock()
+	return da.orderedWrites
+}
+
+// setOverflowQueue replaces the overflow queue, restarting overflow reports.
+// Ordered writes need an overflow queue to order them, so one that blocks is used if none is given.
+func (da *Agent) setOverflowQueue(overflowQueue *overflowQueue) {
+	da.queueLock.Lock()
+	defer da.queueLock.Unlock()
+
+	da.stopOverflowReports()
+	if overflowQueue == nil && da.orderedWrites {
+		overflowQueue = newOverflowQueue(da.eventQueue, QueueOverflowBlock, 0)
+	}
+	da.overflowQueue = overflowQueue
+	if overflowQueue != nil {
+		overflowQueue.onDiscard = da.inFlight.Done
+		overflowQueue.SetOrdered(da.orderedWrites)
+		if overflowQueue.policy != QueueOverflowBlock {
+			da.overflowReportStop = make(chan struct{})
+			go da.reportOverflow(DefaultQueueOverflowReportInterval, da.overflowReportStop)
+		}
+	}
+}
+
+// QueueOverflowPolicy returns the queue overflow policy.
+func (da *Agent) QueueOverflowPolicy() QueueOverflowPolicy {
+	da.queueLock.Lock()
+	defer da.queueLock.Unlock()
+	if da.overflowQueue == nil {
+		return QueueOverflowBlock
+	}
+	return da.overflowQueue.policy
+}
+
+// DroppedEvents returns the number of queued items (writes and listener triggers) dropped for each event because the queue was full.
+func (da *Agent) DroppedEvents() map[EventFlag]int64 {
+	da.queueLock.Lock()
+	defer da.queueLock.Unlock()
+	if da.overflowQueue == nil {
+		return map[EventFlag]int64{}
+	}
+	return da.overflowQueue.Dropped()
+}
+
+// ReportQueueOverflow fires `EventQueueOverflow` if events have been dropped since it was last called.
+// The event is written synchronously, as the queue it would go through is full, with the dropped counts as fields.
+// It is written unless the agent's events disable it explicitly (e.g. with `-queue_overflow`, or `none`).
+// It is called periodically when a queue overflow policy is set.
+func (da *Agent) ReportQueueOverflow() {
+	da.queueLock.Lock()
+	overflowQueue := da.overflowQueue
+	da.queueLock.Unlock()
+	if overflowQueue == nil {
+		return
+	}
+
+	fields, total := eventCountFields(overflowQueue.Unreported())
+	if total == 0 || !da.reportsEvent(EventQueueOverflow) {
+		return
+	}
+	format := "dropped %d queued events"
+	da.write(TimeNow(), EventQueueOverflow, ColorLightYellow, fields, format, total)
+	if da.HasListener(EventQueueOverflow) {
+		da.triggerListeners(appendFields([]interface{}{TimeNow(), EventQueueOverflow, format, total}, fields)...)
+	}
+}
+
+// reportsEvent returns if an event the agent raises about itself (like `EventQueueOverflow`) is written.
+// It is unless the agent's events decide against it, so reporting doesn't need the events (which may be shared) to be changed.
+func (da *Agent) reportsEvent(eventFlag EventFlag) bool {
+	enabled, decided := da.enabledEvents().lookup(eventFlag)
+	return enabled || !decided
 }
 
 // --------------------------------------------------------------------------------
@@ -337,8 +652,36 @@ func (da *Agent) Sync() *SyncAgent {
 // finalizers
 // --------------------------------------------------------------------------------
 
-// Close releases shared resources for the agent.
-func (da *Agent) Close() (err error) {
+// Close waits for the events already queued to finish, then releases shared resources for the agent.
+// If writes are spilled, the writes still queued are spilled instead of waited on. Use `DrainContext` to bound the wait.
+func (da *Agent) Close() error {
+	return da.close(context.Background())
+}
+
+// close waits for queued events to finish until the context is done, then releases shared resources for the agent.
+func (da *Agent) close(ctx context.Context) (err error) {
+	da.queueLock.Lock()
+	da.stopOverflowReports()
+	if da.overflowQueue != nil && da.overflowQueue.spill != nil {
+		da.overflowQueue.SpillPending()
+		da.overflowQueue.spill.Close()
+	}
+	da.queueLock.Unlock()
+
+	// events still queued when the context is done are abandoned, but they may be running; the queue and writer are closed
+	// once they finish, rather than from under them.
+	if err = da.inFlight.Wait(ctx); err != nil {
+		go func() {
+			da.inFlight.Wait(context.Background())
+			da.closeQueueAndWriter()
+		}()
+		return
+	}
+	return da.closeQueueAndWriter()
+}
+
+// closeQueueAndWriter stops the queue's workers, then closes the writer.
+func (da *Agent) closeQueueAndWriter() (err error) {
 	if da.eventQueue != nil {
 		err = da.eventQueue.Close()
 		if err != nil {
@@ -351,23 +694,144 @@ func (da *Agent) Close() (err error) {
 	return
 }
 
-// Drain waits for the agent to finish it's queue of events before closing.
+// Drain disables all events, then waits for the agent to finish it's queue of events before closing.
 func (da *Agent) Drain() error {
 	if da == nil {
 		return nil
 	}
 	da.SetVerbosity(NewEventFlagSetNone())
+	return da.DrainContext(context.Background())
+}
+
+// DrainContext waits for the agent to finish it's queue of events, then closes it.
+// If the context is done first, it returns an `*AbandonedEventsError`; the agent stops reporting overflow and spills what it can,
+// but its queue and writer are only closed once the abandoned events finish, so they never write to a closed output.
+func (da *Agent) DrainContext(ctx context.Context) error {
+	if da == nil {
+		return nil
+	}
+	err := da.Flush(ctx)
+	closeErr := da.close(ctx)
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// Flush waits until every queued write and listener invocation has finished (and spilled writes are replayed).
+// If the context is done first, it returns an `*AbandonedEventsError` with the number of unfinished events.
+func (da *Agent) Flush(ctx context.Context) error {
+	if da == nil {
+		return nil
+	}
+	err := da.inFlight.Wait(ctx)
+	if err != nil {
+		return err
+	}
 
-	for da.eventQueue.Len() > 0 {
-		time.Sleep(time.Millisecond)
+	da.queueLock.Lock()
+	overflowQueue := da.overflowQueue
+	da.queueLock.Unlock()
+	if overflowQueue != nil {
+		return overflowQueue.ReplaySpilled()
 	}
-	return da.Close()
+	return nil
 }
 
 // --------------------------------------------------------------------------------
 // internal methods
 // --------------------------------------------------------------------------------
 
+// enqueue queues an action for a given event, following the queue overflow policy.
+func (da *Agent) enqueue(eventFlag EventFlag, action func(...interface{}) error, args ...interface{}) {
+	da.enqueueWrite(spillKindNone, eventFlag, action, args...)
+}
+
+// enqueueWrite queues an action that is a given kind of write, so it can be spilled if the queue is full.
+func (da *Agent) enqueueWrite(kind spillKind, eventFlag EventFlag, action func(...interface{}) error, args ...interface{}) {
+	da.queueLock.Lock()
+	overflowQueue := da.overflowQueue
+	da.queueLock.Unlock()
+
+	da.inFlight.Add()
+	tracked := func(state ...interface{}) error {
+		defer da.inFlight.Done()
+		return action(state...)
+	}
+	if overflowQueue == nil {
+		da.eventQueue.Enqueue(tracked, args...)
+		return
+	}
+	overflowQueue.EnqueueWrite(kind, eventFlag, tracked, args...)
+}
+
+// replaySpilled writes a spilled record to the writer.
+func (da *Agent) replaySpilled(record *spillRecord) error {
+	if da.writer == nil {
+		return nil
+	}
+	return record.writeTo(da.writer)
+}
+
+// reportOverflow reports dropped events on an interval until stopped.
+func (da *Agent) reportOverflow(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			da.ReportQueueOverflow()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// stopOverflowReports stops reporting dropped events; it must be called with the queue lock held.
+func (da *Agent) stopOverflowReports() {
+	if da.overflowReportStop != nil {
+		close(da.overflowReportStop)
+		da.overflowReportStop = nil
+	}
+}
+
+// registerListener returns a listener with the next id; it must be called with the listeners lock held.
+func (da *Agent) registerListener(eventFlag EventFlag, name string, listener EventListener) registeredListener {
+	da.lastListenerID++
+	return registeredListener{
+		ListenerInfo: ListenerInfo{ID: da.lastListenerID, Name: name, Event: eventFlag},
+		Listener:     listener,
+	}
+}
+
+// removeListenerFrom removes a listener from a map of listeners, returning if it was found.
+func removeListenerFrom(listeners map[EventFlag][]registeredListener, id ListenerID) bool {
+	for eventFlag, eventListeners := range listeners {
+		if remaining, removed := withoutListener(eventListeners, id); removed {
+			if len(remaining) == 0 {
+				delete(listeners, eventFlag)
+			} else {
+				listeners[eventFlag] = remaining
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// withoutListener returns a copy of a set of listeners without a given listener.
+// Listeners are copied rather than removed in place, as they may be being dispatched.
+func withoutListener(listeners []registeredListener, id ListenerID) ([]registeredListener, bool) {
+	for x, registered := range listeners {
+		if registered.ID == id {
+			remaining := make([]registeredListener, 0, len(listeners)-1)
+			remaining = append(remaining, listeners[:x]...)
+			return append(remaining, listeners[x+1:]...), true
+		}
+	}
+	return listeners, false
+}
+
 // triggerListeners triggers the currently configured event listeners.
 func (da *Agent) triggerListeners(actionState ...interface{}) error {
 	if len(actionState) < 2 {
@@ -384,52 +848,108 @@ func (da *Agent) triggerListeners(actionState ...interface{}) error {
 		return err
 	}
 
-	da.eventListenersLock.Lock()
-	listeners := da.eventListeners[eventFlag]
-	da.eventListenersLock.Unlock()
+	table := da.listeners()
+	listeners := table.ListenersFor(eventFlag)
+	debugListeners := table.debug
 
 	for x := 0; x < len(listeners); x++ {
-		listener := listeners[x]
-		listener(da.writer, timeSource, eventFlag, actionState[2:]...)
+		da.invokeListener(listeners[x], timeSource, eventFlag, actionState[2:]...)
 	}
 
-	if len(da.debugListeners) > 0 {
-		for x := 0; x < len(da.debugListeners); x++ {
-			listener := da.debugListeners[x]
-			listener(da.writer, timeSource, eventFlag, actionState[2:]...)
-		}
+	for x := 0; x < len(debugListeners); x++ {
+		da.invokeListener(debugListeners[x], timeSource, eventFlag, actionState[2:]...)
 	}
 
 	return nil
 }
 
-// printf checks an event flag and writes a message with a given color.
-func (da *Agent) queueWrite(eventFlag EventFlag, color AnsiColorCode, format string, args ...interface{}) {
-	if len(format) > 0 {
-		da.eventQueue.Enqueue(da.write, append([]interface{}{TimeNow(), eventFlag, color, format}, args...)...)
+// onEvent fires the listeners for an event that has already been checked to be enabled.
+func (da *Agent) onEvent(eventFlag EventFlag, state ...interface{}) {
+	if da.HasListener(eventFlag) {
+		da.enqueue(eventFlag, da.triggerListeners, append([]interface{}{TimeNow(), eventFlag}, state...)...)
+	}
+}
+
+// writeEventWithFields queues an event that has already been checked to be enabled, and fires its listeners.
+func (da *Agent) writeEventWithFields(event EventFlag, color AnsiColorCode, fields Fields, format string, args ...interface{}) {
+	args = da.captureArgs(args)
+	da.queueWrite(event, color, fields, format, args...)
+
+	if da.HasListener(event) {
+		da.enqueue(event, da.triggerListeners, appendFields(append([]interface{}{TimeNow(), event, format}, args...), fields)...)
+	}
+}
+
+// errorEventWithFields queues an error event that has already been checked to be enabled, and fires its listeners.
+func (da *Agent) errorEventWithFields(event EventFlag, color AnsiColorCode, err error, fields Fields, state ...interface{}) {
+	da.enqueueWrite(spillKindError, event, da.writeErr, TimeNow(), event, color, fields, err)
+	if da.HasListener(event) {
+		da.enqueue(event, da.triggerListeners, appendFields(append([]interface{}{TimeNow(), event, err}, state...), fields)...)
+	}
+}
+
+// queueWrite queues a message with a given color and fields to be written to the output stream.
+func (da *Agent) queueWrite(eventFlag EventFlag, color AnsiColorCode, fields Fields, format string, args ...interface{}) {
+	if len(format) > 0 || len(fields) > 0 {
+		da.enqueueWrite(spillKindOutput, eventFlag, da.write, append([]interface{}{TimeNow(), eventFlag, color, fields, format}, args...)...)
 	}
 }
 
-// errorf checks an event flag and writes a message to the error stream (if one is configured) with a given color.
-func (da *Agent) queueWriteError(eventFlag EventFlag, color AnsiColorCode, format string, args ...interface{}) {
-	if len(format) > 0 {
-		da.eventQueue.Enqueue(da.writeError, append([]interface{}{TimeNow(), eventFlag, color, format}, args...)...)
+// queueWriteError queues a message with a given color and fields to be written to the error stream (if one is configured).
+func (da *Agent) queueWriteError(eventFlag EventFlag, color AnsiColorCode, fields Fields, format string, args ...interface{}) {
+	if len(format) > 0 || len(fields) > 0 {
+		da.enqueueWrite(spillKindErrorOutput, eventFlag, da.writeError, append([]interface{}{TimeNow(), eventFlag, color, fields, format}, args...)...)
 	}
 }
 
 func (da *Agent) write(actionState ...interface{}) error {
-	return da.writeWithOutput(da.writer.PrintfWithTimeSource, actionState...)
+	return da.writeWithOutput(da.writer.PrintEventfWithTimeSource, actionState...)
 }
 
 func (da *Agent) writeError(actionState ...interface{}) error {
-	return da.writeWithOutput(da.writer.ErrorfWithTimeSource, actionState...)
+	return da.writeWithOutput(da.writer.ErrorEventfWithTimeSource, actionState...)
+}
+
+// writeErr writes an error event to the error stream (if one is configured).
+func (da *Agent) writeErr(actionState ...interface{}) error {
+	if len(actionState) < 5 {
+		return nil
+	}
+
+	timeSource, err := stateAsTimeSource(actionState[0])
+	if err != nil {
+		return err
+	}
+
+	eventFlag, err := stateAsEventFlag(actionState[1])
+	if err != nil {
+		return err
+	}
+
+	labelColor, err := stateAsAnsiColorCode(actionState[2])
+	if err != nil {
+		return err
+	}
+
+	fields, err := stateAsFields(actionState[3])
+	if err != nil {
+		return err
+	}
+
+	eventErr, err := stateAsError(actionState[4])
+	if err != nil {
+		return err
+	}
+
+	_, err = da.writer.WriteErrorWithTimeSource(timeSource, eventFlag, labelColor, eventErr, fields)
+	return err
 }
 
-type loggerOutputWithTimeSource func(ts TimeSource, format string, args ...interface{}) (int64, error)
+type loggerOutputWithTimeSource func(ts TimeSource, event EventFlag, color AnsiColorCode, fields Fields, format string, args ...interface{}) (int64, error)
 
-// writeEventMessage writes an event message.
+// writeWithOutput writes an event message.
 func (da *Agent) writeWithOutput(output loggerOutputWithTimeSource, actionState ...interface{}) error {
-	if len(actionState) < 4 {
+	if len(actionState) < 5 {
 		return nil
 	}
 
@@ -448,12 +968,17 @@ func (da *Agent) writeWithOutput(output loggerOutputWithTimeSource, actionState
 		return err
 	}
 
-	format, err := stateAsString(actionState[3])
+	fields, err := stateAsFields(actionState[3])
+	if err != nil {
+		return err
+	}
+
+	format, err := stateAsString(actionState[4])
 	if err != nil {
 		return err
 	}
 
-	_, err = output(timeSource, "%s %s", da.writer.FormatEvent(eventFlag, labelColor), fmt.Sprintf(format, actionState[4:]...))
+	_, err = output(timeSource, eventFlag, labelColor, fields, format, actionState[5:]...)
 	return err
 }
 
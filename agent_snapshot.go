@@ -0,0 +1,87 @@
+package logger
+
+// The agent publishes immutable snapshots of its events and listeners, so the checks made on every call
+// (`IsEnabled`, `HasListener`) and listener dispatch don't take a lock.
+// Writers still serialize on the events and listeners locks, then swap in a new snapshot.
+
+// listenerTable is an immutable snapshot of an agent's listeners.
+type listenerTable struct {
+	events   map[EventFlag][]registeredListener
+	subtrees map[EventFlag][]registeredListener
+	debug    []registeredListener
+}
+
+// HasListener returns if there are listeners for an event, or for a wildcard above it.
+func (lt *listenerTable) HasListener(eventFlag EventFlag) bool {
+	if len(lt.events[eventFlag]) > 0 {
+		return true
+	}
+	if len(lt.subtrees) == 0 {
+		return false
+	}
+	for ancestor, hasParent := eventFlag.Parent(); hasParent; ancestor, hasParent = ancestor.Parent() {
+		if len(lt.subtrees[ancestor]) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ListenersFor returns the listeners for an event, followed by the wildcard listeners above it (nearest first).
+func (lt *listenerTable) ListenersFor(eventFlag EventFlag) []registeredListener {
+	listeners := lt.events[eventFlag]
+	if len(lt.subtrees) == 0 {
+		return listeners
+	}
+	for ancestor, hasParent := eventFlag.Parent(); hasParent; ancestor, hasParent = ancestor.Parent() {
+		if subtreeListeners := lt.subtrees[ancestor]; len(subtreeListeners) > 0 {
+			listeners = append(listeners[:len(listeners):len(listeners)], subtreeListeners...)
+		}
+	}
+	return listeners
+}
+
+// enabledEvents returns the current snapshot of the agent's events.
+func (da *Agent) enabledEvents() *EventFlagSet {
+	if events, ok := da.eventsSnapshot.Load().(*EventFlagSet); ok {
+		return events
+	}
+	da.eventsLock.Lock()
+	defer da.eventsLock.Unlock()
+	return da.publishEvents()
+}
+
+// publishEvents swaps in a snapshot of the agent's events; it must be called with the events lock held.
+func (da *Agent) publishEvents() *EventFlagSet {
+	snapshot := da.events.clone()
+	da.eventsSnapshot.Store(snapshot)
+	return snapshot
+}
+
+// listeners returns the current snapshot of the agent's listeners.
+func (da *Agent) listeners() *listenerTable {
+	if table, ok := da.listenerSnapshot.Load().(*listenerTable); ok {
+		return table
+	}
+	da.eventListenersLock.Lock()
+	defer da.eventListenersLock.Unlock()
+	return da.publishListeners()
+}
+
+// publishListeners swaps in a snapshot of the agent's listeners; it must be called with the listeners lock held.
+// Listener slices are never modified in place once added, so only the maps are copied.
+func (da *Agent) publishListeners() *listenerTable {
+	table := &listenerTable{
+		events:   make(map[EventFlag][]registeredListener, len(da.eventListeners)),
+		subtrees: make(map[EventFlag][]registeredListener, len(da.subtreeListeners)),
+		debug:    da.debugListeners,
+	}
+	for eventFlag, listeners := range da.eventListeners {
+		table.events[eventFlag] = listeners
+	}
+	for subtree, listeners := range da.subtreeListeners {
+		table.subtrees[subtree] = listeners
+	}
+	da.listenerSnapshot.Store(table)
+	return table
+}
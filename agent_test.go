@@ -2,7 +2,9 @@ package logger
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strings"
 	"sync"
@@ -250,12 +252,57 @@ func TestAgentWriteEventMessageWithOutput(t *testing.T) {
 	da.writer.SetUseAnsiColors(false)
 
 	ts := TimeInstance(time.Date(2016, 01, 02, 03, 04, 05, 06, time.UTC))
-	err := da.writeWithOutput(da.writer.PrintfWithTimeSource, ts, EventFlag("test"), ColorWhite, "%s World", "Hello")
+	err := da.writeWithOutput(da.writer.PrintEventfWithTimeSource, ts, EventFlag("test"), ColorWhite, Fields(nil), "%s World", "Hello")
 	assert.Nil(err)
 	assert.True(strings.HasPrefix(buffer.String(), time.Time(ts).Format(DefaultTimeFormat)))
 	assert.True(strings.HasSuffix(buffer.String(), "Hello World\n"))
 }
 
+func TestAgentInfoWithFields(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := bytes.NewBuffer([]byte{})
+	da := All(NewWriter(buffer))
+	defer da.Close()
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	da.AddEventListener(EventInfo, NewFieldsListener(func(wr *Writer, ts TimeSource, e EventFlag, fields Fields, state ...interface{}) {
+		defer wg.Done()
+		assert.Equal(NewFields("user_id", 123), fields)
+		assert.Len(state, 2)
+		assert.Equal("Hello %s", state[0])
+		assert.Equal("World", state[1])
+	}))
+
+	da.InfoWithFields(NewFields("user_id", 123), "Hello %s", "World")
+	wg.Wait()
+}
+
+func TestAgentErrorWithFields(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := bytes.NewBuffer([]byte{})
+	da := All(NewWriter(buffer))
+	defer da.Close()
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	da.AddEventListener(EventError, NewErrorListener(func(wr *Writer, ts TimeSource, err error) {
+		defer wg.Done()
+		assert.Equal("this is only a test", err.Error())
+	}))
+	da.AddEventListener(EventError, func(wr *Writer, ts TimeSource, e EventFlag, state ...interface{}) {
+		defer wg.Done()
+		assert.Equal(NewFields("tenant", "acme"), FieldsFromState(state...))
+	})
+	wg.Add(1)
+
+	err := da.ErrorWithFields(fmt.Errorf("this is only a test"), NewFields("tenant", "acme"))
+	assert.NotNil(err)
+	wg.Wait()
+}
+
 func TestAgentRemoveListeners(t *testing.T) {
 	assert := assert.New(t)
 
@@ -268,6 +315,225 @@ func TestAgentRemoveListeners(t *testing.T) {
 
 }
 
+func TestAgentRemoveListener(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := bytes.NewBuffer([]byte{})
+	da := NewWithWriter(NewEventFlagSet(EventInfo), NewWriter(buffer))
+	defer da.Close()
+
+	var calls []string
+	first := da.AddEventListener(EventInfo, func(writer *Writer, ts TimeSource, eventFlag EventFlag, state ...interface{}) {
+		calls = append(calls, "first")
+	})
+	da.AddEventListener(EventInfo, func(writer *Writer, ts TimeSource, eventFlag EventFlag, state ...interface{}) {
+		calls = append(calls, "second")
+	})
+
+	assert.True(da.RemoveListener(first))
+	assert.False(da.RemoveListener(first))
+	assert.True(da.HasListener(EventInfo))
+
+	da.Sync().OnEvent(EventInfo)
+	assert.Equal([]string{"second"}, calls)
+}
+
+func TestAgentListeners(t *testing.T) {
+	assert := assert.New(t)
+
+	da := New(NewEventFlagSetAll())
+	defer da.Close()
+
+	errorID := da.AddNamedEventListener(EventError, "webhook", func(writer *Writer, ts TimeSource, eventFlag EventFlag, state ...interface{}) {})
+	infoID := da.AddEventListener(EventInfo, func(writer *Writer, ts TimeSource, eventFlag EventFlag, state ...interface{}) {})
+	debugID := da.AddNamedDebugListener("tracer", func(writer *Writer, ts TimeSource, eventFlag EventFlag, state ...interface{}) {})
+
+	assert.Equal([]ListenerInfo{
+		{ID: errorID, Name: "webhook", Event: EventError},
+		{ID: infoID, Event: EventInfo},
+		{ID: debugID, Name: "tracer", Event: EventAll},
+	}, da.Listeners())
+
+	assert.True(da.RemoveListener(debugID))
+	assert.Len(da.Listeners(), 2)
+}
+
+func TestAgentWildcardListener(t *testing.T) {
+	assert := assert.New(t)
+
+	da := New(NewEventFlagSetAll())
+	defer da.Close()
+
+	var events []EventFlag
+	id := da.AddEventListener("web.*", func(writer *Writer, ts TimeSource, eventFlag EventFlag, state ...interface{}) {
+		events = append(events, eventFlag)
+	})
+	assert.True(da.HasListener(EventWebRequest))
+	assert.True(da.HasListener(EventWebRequestStart))
+	assert.False(da.HasListener("web"))
+	assert.False(da.HasListener(EventInfo))
+
+	da.Sync().OnEvent(EventWebRequestStart)
+	da.Sync().OnEvent(EventWebResponse)
+	da.Sync().OnEvent(EventInfo)
+	assert.Equal([]EventFlag{EventWebRequestStart, EventWebResponse}, events)
+	assert.Equal([]ListenerInfo{{ID: id, Event: "web.*"}}, da.Listeners())
+
+	da.RemoveListeners("web.*")
+	assert.False(da.HasListener(EventWebRequest))
+}
+
+func TestAgentListenersConcurrentDispatch(t *testing.T) {
+	assert := assert.New(t)
+
+	da := NewWithWriter(NewEventFlagSet(EventInfo), NewWriter(bytes.NewBuffer([]byte{})))
+	defer da.Close()
+	da.AddEventListener(EventInfo, func(writer *Writer, ts TimeSource, eventFlag EventFlag, state ...interface{}) {})
+
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for x := 0; x < 100; x++ {
+			da.OnEvent(EventInfo, x)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for x := 0; x < 100; x++ {
+			id := da.AddEventListener(EventInfo, func(writer *Writer, ts TimeSource, eventFlag EventFlag, state ...interface{}) {})
+			da.RemoveListener(id)
+		}
+	}()
+	wg.Wait()
+	assert.Nil(da.Flush(context.Background()))
+	assert.Len(da.Listeners(), 1)
+}
+
+func TestAgentFlush(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := bytes.NewBuffer([]byte{})
+	writer := NewWriter(buffer)
+	writer.SetUseAnsiColors(false)
+	da := NewWithWriter(NewEventFlagSet(EventInfo, EventError), writer)
+	defer da.Close()
+
+	release := make(chan struct{})
+	da.AddEventListener(EventError, func(writer *Writer, ts TimeSource, eventFlag EventFlag, state ...interface{}) {
+		<-release
+	})
+	da.OnEvent(EventError, "Hello")
+	da.Infof("this is only a test")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := da.Flush(ctx)
+	assert.NotNil(err)
+	abandoned, ok := err.(*AbandonedEventsError)
+	assert.True(ok)
+	assert.Equal(int64(1), abandoned.Abandoned)
+	assert.Equal(context.DeadlineExceeded, abandoned.Err)
+	assert.True(da.IsEnabled(EventInfo))
+
+	close(release)
+	assert.Nil(da.Flush(context.Background()))
+	assert.Contains(buffer.String(), "[info] this is only a test")
+}
+
+func TestAgentDrainContext(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := bytes.NewBuffer([]byte{})
+	writer := NewWriter(buffer)
+	writer.SetUseAnsiColors(false)
+	da := NewWithWriter(NewEventFlagSet(EventInfo), writer)
+
+	for x := 0; x < 100; x++ {
+		da.Infof("event %d", x)
+	}
+	assert.Nil(da.DrainContext(context.Background()))
+	assert.Equal(100, strings.Count(buffer.String(), "[info] event"))
+	assert.True(da.IsEnabled(EventInfo))
+}
+
+// closeTrackingOutput is an output that records writes made after it was closed.
+type closeTrackingOutput struct {
+	lock             sync.Mutex
+	buffer           bytes.Buffer
+	closed           chan struct{}
+	writesAfterClose int
+}
+
+func (cto *closeTrackingOutput) Write(contents []byte) (int, error) {
+	cto.lock.Lock()
+	defer cto.lock.Unlock()
+	select {
+	case <-cto.closed:
+		cto.writesAfterClose++
+	default:
+	}
+	return cto.buffer.Write(contents)
+}
+
+func (cto *closeTrackingOutput) Close() error {
+	close(cto.closed)
+	return nil
+}
+
+func TestAgentDrainContextAbandoned(t *testing.T) {
+	assert := assert.New(t)
+
+	output := &closeTrackingOutput{closed: make(chan struct{})}
+	writer := NewWriter(nil)
+	writer.Output = output
+	writer.SetUseAnsiColors(false)
+	da := NewWithWriter(NewEventFlagSet(EventInfo), writer)
+
+	release := make(chan struct{})
+	da.AddEventListener(EventInfo, func(wr *Writer, ts TimeSource, eventFlag EventFlag, state ...interface{}) {
+		<-release
+		wr.Printf("slow listener finished")
+	})
+	da.Infof("this is only a test")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := da.DrainContext(ctx)
+	_, isAbandoned := err.(*AbandonedEventsError)
+	assert.True(isAbandoned)
+
+	var closedEarly bool
+	select {
+	case <-output.closed:
+		closedEarly = true
+	default:
+	}
+	assert.False(closedEarly, "the output shouldn't be closed while an abandoned event is running")
+
+	close(release)
+	<-output.closed
+	output.lock.Lock()
+	defer output.lock.Unlock()
+	assert.Zero(output.writesAfterClose)
+	assert.Contains(output.buffer.String(), "slow listener finished")
+}
+
+func TestAgentCloseWaitsForQueuedEvents(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := bytes.NewBuffer([]byte{})
+	writer := NewWriter(buffer)
+	writer.SetUseAnsiColors(false)
+	da := NewWithWriter(NewEventFlagSet(EventInfo), writer)
+
+	for x := 0; x < 100; x++ {
+		da.Infof("event %d", x)
+	}
+	assert.Nil(da.Close())
+	assert.Equal(100, strings.Count(buffer.String(), "[info] event"))
+}
+
 func BenchmarkAgentIsEnabled(b *testing.B) {
 	for iter := 0; iter < b.N; iter++ {
 		for subIter := 0; subIter < 50; subIter++ {
@@ -280,3 +546,145 @@ func BenchmarkAgentIsEnabled(b *testing.B) {
 		}
 	}
 }
+
+func TestAgentIsEnabledConcurrent(t *testing.T) {
+	assert := assert.New(t)
+
+	da := New(NewEventFlagSet(EventInfo))
+	defer da.Close()
+
+	wg := sync.WaitGroup{}
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		for x := 0; x < 1000; x++ {
+			da.IsEnabled(EventDebug)
+			da.HasListener(EventDebug)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for x := 0; x < 1000; x++ {
+			da.EnableEvent(EventDebug)
+			da.DisableEvent(EventDebug)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for x := 0; x < 1000; x++ {
+			id := da.AddEventListener(EventDebug, func(writer *Writer, ts TimeSource, eventFlag EventFlag, state ...interface{}) {})
+			da.RemoveListener(id)
+		}
+	}()
+	wg.Wait()
+
+	da.EnableEvent(EventDebug)
+	assert.True(da.IsEnabled(EventDebug))
+	assert.True(da.IsEnabled(EventInfo))
+	assert.False(da.HasListener(EventDebug))
+}
+
+// benchmarkAgentParallelism is how many goroutines per cpu the parallel benchmarks run.
+const benchmarkAgentParallelism = 64
+
+func BenchmarkAgentIsEnabledParallel(b *testing.B) {
+	da := New(NewEventFlagSet(EventFatalError, EventError, EventWebRequest, EventInfo))
+	defer da.Close()
+	b.SetParallelism(benchmarkAgentParallelism)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			da.IsEnabled(EventInfo)
+			da.IsEnabled(EventWebRequestStart)
+		}
+	})
+}
+
+// BenchmarkAgentIsEnabledLockedParallel checks enabled events the way `IsEnabled` did before it read snapshots,
+// under the agent's events lock, to compare against `BenchmarkAgentIsEnabledParallel`.
+func BenchmarkAgentIsEnabledLockedParallel(b *testing.B) {
+	da := New(NewEventFlagSet(EventFatalError, EventError, EventWebRequest, EventInfo))
+	defer da.Close()
+	isEnabledLocked := func(flagValue EventFlag) bool {
+		da.eventsLock.Lock()
+		enabled := da.events.IsEnabled(flagValue)
+		da.eventsLock.Unlock()
+		return enabled
+	}
+	b.SetParallelism(benchmarkAgentParallelism)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			isEnabledLocked(EventInfo)
+			isEnabledLocked(EventWebRequestStart)
+		}
+	})
+}
+
+func BenchmarkAgentHasListenerParallel(b *testing.B) {
+	da := New(NewEventFlagSetAll())
+	defer da.Close()
+	da.AddEventListener(EventInfo, func(writer *Writer, ts TimeSource, eventFlag EventFlag, state ...interface{}) {})
+	b.SetParallelism(benchmarkAgentParallelism)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			da.HasListener(EventInfo)
+			da.HasListener(EventWebRequest)
+		}
+	})
+}
+
+func TestAgentOrderedWrites(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := bytes.NewBuffer(nil)
+	writer := NewWriter(buffer)
+	writer.SetShowTimestamp(false)
+	writer.SetUseAnsiColors(false)
+	da := NewWithWriter(NewEventFlagSet(EventInfo), writer)
+	defer da.Close()
+	da.SetOrderedWrites(true)
+	assert.True(da.OrderedWrites())
+	assert.Equal(QueueOverflowBlock, da.QueueOverflowPolicy())
+
+	listened := make(chan struct{}, 100)
+	da.AddEventListener(EventInfo, func(writer *Writer, ts TimeSource, eventFlag EventFlag, state ...interface{}) {
+		listened <- struct{}{}
+	})
+
+	var expected []string
+	for x := 0; x < 100; x++ {
+		da.Infof("event %d", x)
+		expected = append(expected, fmt.Sprintf("[info] event %d", x))
+	}
+	assert.Nil(da.Flush(context.Background()))
+	assert.Len(listened, 100)
+
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		lines = append(lines, strings.TrimSpace(line))
+	}
+	assert.Equal(expected, lines)
+
+	da.SetQueueOverflowPolicy(QueueOverflowDropNewest, 0)
+	assert.True(da.overflowQueue.ordered)
+	da.SetOrderedWrites(false)
+	assert.False(da.overflowQueue.ordered)
+}
+
+func benchmarkAgentWrites(b *testing.B, orderedWrites bool) {
+	da := NewWithWriter(NewEventFlagSet(EventInfo), NewWriter(ioutil.Discard))
+	defer da.Close()
+	da.SetOrderedWrites(orderedWrites)
+	b.ResetTimer()
+	for iter := 0; iter < b.N; iter++ {
+		da.Infof("this is event %d", iter)
+	}
+	da.Flush(context.Background())
+}
+
+func BenchmarkAgentWrites(b *testing.B) {
+	benchmarkAgentWrites(b, false)
+}
+
+func BenchmarkAgentWritesOrdered(b *testing.B) {
+	benchmarkAgentWrites(b, true)
+}
@@ -0,0 +1,240 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	exception "github.com/blendlabs/go-exception"
+)
+
+const (
+	// ArchiveNamingIndex is the name of the index archive naming strategy.
+	ArchiveNamingIndex = "index"
+	// ArchiveNamingTimestamp is the name of the timestamp archive naming strategy.
+	ArchiveNamingTimestamp = "timestamp"
+
+	// ArchiveTimestampFormat is the time format timestamp archive names use.
+	ArchiveTimestampFormat = "2006-01-02T15-04-05"
+
+	archiveCompressedSuffix = ".gz"
+
+	isTimestampArchiveFileRegexpFormat = `^%s\.([0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9]{2}-[0-9]{2}-[0-9]{2})(?:\.([0-9]+))?%s$`
+)
+
+// ParseArchiveNaming returns the archive naming strategy for a given name (`index` or `timestamp`).
+// An empty name returns the index strategy.
+func ParseArchiveNaming(name string) (ArchiveNaming, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", ArchiveNamingIndex:
+		return IndexArchiveNaming{}, nil
+	case ArchiveNamingTimestamp:
+		return TimestampArchiveNaming{}, nil
+	default:
+		return nil, exception.Newf("Invalid archive naming `%s`", name)
+	}
+}
+
+// ArchiveNaming is a strategy for naming the archives of a rotated file.
+// Archive paths are returned without the `.gz` suffix compressed archives have.
+type ArchiveNaming interface {
+	// Regexp returns a regexp that matches the base names of the archives of a file.
+	Regexp(filePath string, compressed bool) (*regexp.Regexp, error)
+	// Prepare makes room for a new archive given the existing archives, and returns the path the file should be archived to.
+	Prepare(filePath string, archives []string, rotatedAt time.Time, compressed bool, maxArchiveCount int64) (string, error)
+	// Sort orders archives from oldest to newest.
+	Sort(filePath string, archives []string, compressed bool) ([]string, error)
+}
+
+// IndexArchiveNaming names archives with an index, `app.log.1` being the newest.
+// Every rotation renames each archive to the next index.
+type IndexArchiveNaming struct{}
+
+// Regexp implements ArchiveNaming.
+func (ian IndexArchiveNaming) Regexp(filePath string, compressed bool) (*regexp.Regexp, error) {
+	if compressed {
+		return createIsCompressedArchiveFileRegexp(filePath)
+	}
+	return createIsArchivedFileRegexp(filePath)
+}
+
+// Prepare implements ArchiveNaming.
+// It shifts the existing archives up an index, removing those past the max archive count.
+func (ian IndexArchiveNaming) Prepare(filePath string, archives []string, rotatedAt time.Time, compressed bool, maxArchiveCount int64) (string, error) {
+	expr, err := ian.Regexp(filePath, compressed)
+	if err != nil {
+		return "", err
+	}
+	err = shiftIndexedArchives(filePath, expr, archives, compressed, maxArchiveCount)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s.%d", filePath, 1), nil
+}
+
+// Sort implements ArchiveNaming.
+func (ian IndexArchiveNaming) Sort(filePath string, archives []string, compressed bool) ([]string, error) {
+	expr, err := ian.Regexp(filePath, compressed)
+	if err != nil {
+		return nil, err
+	}
+	indexes := make(map[string]int64, len(archives))
+	for _, archive := range archives {
+		index, err := extractArchiveIndex(expr, archive)
+		if err != nil {
+			return nil, err
+		}
+		indexes[archive] = index
+	}
+
+	sorted := append([]string(nil), archives...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return indexes[sorted[i]] > indexes[sorted[j]]
+	})
+	return sorted, nil
+}
+
+// TimestampArchiveNaming names archives with the time they were rotated, e.g. `app.log.2017-03-04T15-00-00`.
+// Archives rotated within the same second get a sequence suffix (`app.log.2017-03-04T15-00-00.1`).
+// Existing archives are never renamed.
+type TimestampArchiveNaming struct {
+	// Location is the time zone timestamps are written (and sorted) in, so archives sort the same whatever zone they were rotated in.
+	// If unset, UTC is used.
+	Location *time.Location
+}
+
+// location returns the time zone timestamps are written in.
+func (tan TimestampArchiveNaming) location() *time.Location {
+	if tan.Location == nil {
+		return time.UTC
+	}
+	return tan.Location
+}
+
+// Regexp implements ArchiveNaming.
+func (tan TimestampArchiveNaming) Regexp(filePath string, compressed bool) (*regexp.Regexp, error) {
+	var suffix string
+	if compressed {
+		suffix = regexp.QuoteMeta(archiveCompressedSuffix)
+	}
+	return regexp.Compile(fmt.Sprintf(isTimestampArchiveFileRegexpFormat, regexp.QuoteMeta(filepath.Base(filePath)), suffix))
+}
+
+// Prepare implements ArchiveNaming.
+// Pruning is left to the file output, so the max archive count is ignored.
+func (tan TimestampArchiveNaming) Prepare(filePath string, archives []string, rotatedAt time.Time, compressed bool, maxArchiveCount int64) (string, error) {
+	archivePath := fmt.Sprintf("%s.%s", filePath, rotatedAt.In(tan.location()).Format(ArchiveTimestampFormat))
+	candidate := archivePath
+	for sequence := 1; tan.exists(candidate, compressed); sequence++ {
+		candidate = fmt.Sprintf("%s.%d", archivePath, sequence)
+	}
+	return candidate, nil
+}
+
+// Sort implements ArchiveNaming.
+// Archives are ordered by their embedded timestamp, then by sequence.
+func (tan TimestampArchiveNaming) Sort(filePath string, archives []string, compressed bool) ([]string, error) {
+	expr, err := tan.Regexp(filePath, compressed)
+	if err != nil {
+		return nil, err
+	}
+
+	times := make(map[string]time.Time, len(archives))
+	sequences := make(map[string]int64, len(archives))
+	for _, archive := range archives {
+		values := expr.FindStringSubmatch(filepath.Base(archive))
+		if len(values) < 3 {
+			return nil, exception.Newf("Cannot extract archive time from `%s`", filepath.Base(archive))
+		}
+		times[archive], err = time.ParseInLocation(ArchiveTimestampFormat, values[1], tan.location())
+		if err != nil {
+			return nil, exception.Wrap(err)
+		}
+		if len(values[2]) > 0 {
+			sequences[archive], err = strconv.ParseInt(values[2], 10, 64)
+			if err != nil {
+				return nil, exception.Wrap(err)
+			}
+		}
+	}
+
+	sorted := append([]string(nil), archives...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if times[sorted[i]].Equal(times[sorted[j]]) {
+			return sequences[sorted[i]] < sequences[sorted[j]]
+		}
+		return times[sorted[i]].Before(times[sorted[j]])
+	})
+	return sorted, nil
+}
+
+func (tan TimestampArchiveNaming) exists(archivePath string, compressed bool) bool {
+	if compressed {
+		archivePath = archivePath + archiveCompressedSuffix
+	}
+	_, err := os.Stat(archivePath)
+	return err == nil
+}
+
+// shiftIndexedArchives renames each indexed archive to the next index, removing archives that would exceed the max archive count.
+func shiftIndexedArchives(filePath string, expr *regexp.Regexp, paths []string, compressed bool, maxArchiveCount int64) error {
+	var index int64
+	var err error
+
+	intermediatePaths := make(map[string]string)
+	var tempPath, finalPath string
+
+	for _, path := range paths {
+		index, err = extractArchiveIndex(expr, path)
+		if err != nil {
+			return err
+		}
+		if compressed {
+			tempPath = fmt.Sprintf("%s.%d.gz.tmp", filePath, index+1)
+			finalPath = fmt.Sprintf("%s.%d.gz", filePath, index+1)
+		} else {
+			tempPath = fmt.Sprintf("%s.%d.tmp", filePath, index+1)
+			finalPath = fmt.Sprintf("%s.%d", filePath, index+1)
+		}
+
+		if maxArchiveCount > 0 {
+			if index+1 <= maxArchiveCount {
+				err = os.Rename(path, tempPath)
+				intermediatePaths[tempPath] = finalPath
+			} else {
+				err = os.Remove(path)
+			}
+		} else {
+			err = os.Rename(path, tempPath)
+			intermediatePaths[tempPath] = finalPath
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for from, to := range intermediatePaths {
+		err = os.Rename(from, to)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// extractArchiveIndex returns the index of an indexed archive.
+func extractArchiveIndex(expr *regexp.Regexp, filePath string) (int64, error) {
+	filePathBase := filepath.Base(filePath)
+	values := expr.FindStringSubmatch(filePathBase)
+	if len(values) > 1 {
+		value, err := strconv.ParseInt(values[1], 10, 32)
+		return value, exception.Wrap(err)
+	}
+	return 0, exception.Newf("Cannot extract file index from `%s`", filePathBase)
+}
@@ -0,0 +1,105 @@
+package logger
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	assert "github.com/blendlabs/go-assert"
+)
+
+func TestParseArchiveNaming(t *testing.T) {
+	assert := assert.New(t)
+
+	naming, err := ParseArchiveNaming("")
+	assert.Nil(err)
+	assert.Equal(IndexArchiveNaming{}, naming)
+
+	naming, err = ParseArchiveNaming("Timestamp")
+	assert.Nil(err)
+	assert.Equal(TimestampArchiveNaming{}, naming)
+
+	_, err = ParseArchiveNaming("random")
+	assert.NotNil(err)
+}
+
+func TestTimestampArchiveNamingRegexp(t *testing.T) {
+	assert := assert.New(t)
+
+	naming := TimestampArchiveNaming{}
+	uncompressed, err := naming.Regexp("/var/log/app.log", false)
+	assert.Nil(err)
+	assert.True(uncompressed.MatchString("app.log.2017-03-04T15-00-00"))
+	assert.True(uncompressed.MatchString("app.log.2017-03-04T15-00-00.2"))
+	assert.False(uncompressed.MatchString("app.log.2017-03-04T15-00-00.gz"))
+	assert.False(uncompressed.MatchString("app.log.1"))
+	assert.False(uncompressed.MatchString("appxlog.2017-03-04T15-00-00"))
+
+	compressed, err := naming.Regexp("/var/log/app.log", true)
+	assert.Nil(err)
+	assert.True(compressed.MatchString("app.log.2017-03-04T15-00-00.gz"))
+	assert.True(compressed.MatchString("app.log.2017-03-04T15-00-00.1.gz"))
+	assert.False(compressed.MatchString("app.log.2017-03-04T15-00-00"))
+	assert.False(compressed.MatchString("app.log.2017-03-04T15-00-00.gz.tmp"))
+}
+
+func TestTimestampArchiveNamingPrepare(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+
+	filePath := filepath.Join(tempDir, "app.log")
+	rotatedAt := time.Date(2017, 03, 04, 15, 0, 0, 0, time.UTC)
+
+	naming := TimestampArchiveNaming{}
+	archivePath, err := naming.Prepare(filePath, nil, rotatedAt, true, 0)
+	assert.Nil(err)
+	assert.Equal(filePath+".2017-03-04T15-00-00", archivePath)
+
+	assert.Nil(File.CreateAndClose(archivePath + ".gz"))
+	archivePath, err = naming.Prepare(filePath, nil, rotatedAt, true, 0)
+	assert.Nil(err)
+	assert.Equal(filePath+".2017-03-04T15-00-00.1", archivePath)
+
+	// the rotation time's own zone doesn't change the name.
+	archivePath, err = naming.Prepare(filePath, nil, rotatedAt.In(time.FixedZone("UTC+2", 2*60*60)), true, 0)
+	assert.Nil(err)
+	assert.Equal(filePath+".2017-03-04T15-00-00.1", archivePath)
+
+	located := TimestampArchiveNaming{Location: time.FixedZone("UTC-8", -8*60*60)}
+	archivePath, err = located.Prepare(filePath, nil, rotatedAt, true, 0)
+	assert.Nil(err)
+	assert.Equal(filePath+".2017-03-04T07-00-00", archivePath)
+}
+
+func TestTimestampArchiveNamingSort(t *testing.T) {
+	assert := assert.New(t)
+
+	naming := TimestampArchiveNaming{}
+	sorted, err := naming.Sort("app.log", []string{
+		"app.log.2017-03-04T15-00-00.1",
+		"app.log.2017-03-05T09-00-00",
+		"app.log.2017-03-04T15-00-00",
+		"app.log.2016-12-31T23-00-00",
+	}, false)
+	assert.Nil(err)
+	assert.Equal([]string{
+		"app.log.2016-12-31T23-00-00",
+		"app.log.2017-03-04T15-00-00",
+		"app.log.2017-03-04T15-00-00.1",
+		"app.log.2017-03-05T09-00-00",
+	}, sorted)
+}
+
+func TestIndexArchiveNamingSort(t *testing.T) {
+	assert := assert.New(t)
+
+	naming := IndexArchiveNaming{}
+	sorted, err := naming.Sort("app.log", []string{"app.log.2", "app.log.10", "app.log.1"}, false)
+	assert.Nil(err)
+	assert.Equal([]string{"app.log.10", "app.log.2", "app.log.1"}, sorted)
+}
@@ -6,6 +6,9 @@ const (
 	// EnvironmentVariableLogEvents is the log verbosity environment variable.
 	EnvironmentVariableLogEvents = "LOG_EVENTS"
 
+	// EnvironmentVariableLogLevel is the minimum severity of events to enable (`debug`, `info`, `warning`, `error` or `fatal`).
+	EnvironmentVariableLogLevel = "LOG_LEVEL"
+
 	// EnvironmentVariableUseAnsiColors is the env var that controls if we use ansi colors in output.
 	EnvironmentVariableUseAnsiColors = "LOG_USE_COLOR"
 	// EnvironmentVariableShowTimestamp is the env var that controls if we show timestamps in output.
@@ -14,6 +17,21 @@ const (
 	EnvironmentVariableShowLabel = "LOG_SHOW_LABEL"
 	// EnvironmentVariableLogLabel is the env var that sets the descriptive label in output.
 	EnvironmentVariableLogLabel = "LOG_LABEL"
+	// EnvironmentVariableLogFormat is the env var that sets the output format (`text`, `json` or `logfmt`).
+	EnvironmentVariableLogFormat = "LOG_FORMAT"
+
+	// EnvironmentVariableLogQueueOverflow is what the agent does with events when its queue is full (`block`, `drop_newest`, `drop_oldest` or `block_timeout`).
+	EnvironmentVariableLogQueueOverflow = "LOG_QUEUE_OVERFLOW"
+	// EnvironmentVariableLogQueueOverflowTimeout is how long the `block_timeout` queue overflow policy blocks for (defaults to 100ms).
+	EnvironmentVariableLogQueueOverflowTimeout = "LOG_QUEUE_OVERFLOW_TIMEOUT"
+	// EnvironmentVariableLogQueueSpillFile is a file writes that don't fit in the agent's queue are spilled to (and replayed from).
+	EnvironmentVariableLogQueueSpillFile = "LOG_QUEUE_SPILL_FILE"
+	// EnvironmentVariableLogListenerTimeout is how long an event listener can run before it is reported as slow and no longer waited on.
+	EnvironmentVariableLogListenerTimeout = "LOG_LISTENER_TIMEOUT"
+	// EnvironmentVariableLogSnapshotArgs sets if format args are snapshotted when an event is logged, rather than read when it is written.
+	EnvironmentVariableLogSnapshotArgs = "LOG_SNAPSHOT_ARGS"
+	// EnvironmentVariableLogOrderedWrites sets if the agent writes events in the order they were logged.
+	EnvironmentVariableLogOrderedWrites = "LOG_ORDERED_WRITES"
 
 	// EnvironmentVariableLogOutFile is the variable for what file to write to.
 	EnvironmentVariableLogOutFile = "LOG_OUT_FILE"
@@ -34,4 +52,66 @@ const (
 	EnvironmentVariableLogOutMaxArchive = "LOG_OUT_MAX_ARCHIVE"
 	// EnvironmentVariableLogErrMaxSizeBytes
 	EnvironmentVariableLogErrMaxArchive = "LOG_ERR_MAX_ARCHIVE"
+
+	// EnvironmentVariableLogOutRotate is the rotation schedule for the output file (`hourly`, `daily` or a duration like `30m`).
+	EnvironmentVariableLogOutRotate = "LOG_OUT_ROTATE"
+	// EnvironmentVariableLogOutRotateTZ is the time zone the output file rotation schedule is aligned to (defaults to UTC).
+	EnvironmentVariableLogOutRotateTZ = "LOG_OUT_ROTATE_TZ"
+	// EnvironmentVariableLogOutArchiveNaming is how output file archives are named (`index` or `timestamp`, defaults to `index`).
+	EnvironmentVariableLogOutArchiveNaming = "LOG_OUT_ARCHIVE_NAMING"
+	// EnvironmentVariableLogOutMaxAge is how long output file archives are kept (e.g. `30d` or `72h`, defaults to forever).
+	EnvironmentVariableLogOutMaxAge = "LOG_OUT_MAX_AGE"
+	// EnvironmentVariableLogOutMaxTotalBytes is the max size of all output file archives together (e.g. `5gb`, defaults to unlimited).
+	EnvironmentVariableLogOutMaxTotalBytes = "LOG_OUT_MAX_TOTAL_BYTES"
+	// EnvironmentVariableLogErrRotate is the rotation schedule for the error file (`hourly`, `daily` or a duration like `30m`).
+	EnvironmentVariableLogErrRotate = "LOG_ERR_ROTATE"
+	// EnvironmentVariableLogErrRotateTZ is the time zone the error file rotation schedule is aligned to (defaults to UTC).
+	EnvironmentVariableLogErrRotateTZ = "LOG_ERR_ROTATE_TZ"
+	// EnvironmentVariableLogErrArchiveNaming is how error file archives are named (`index` or `timestamp`, defaults to `index`).
+	EnvironmentVariableLogErrArchiveNaming = "LOG_ERR_ARCHIVE_NAMING"
+	// EnvironmentVariableLogErrMaxAge is how long error file archives are kept (e.g. `30d` or `72h`, defaults to forever).
+	EnvironmentVariableLogErrMaxAge = "LOG_ERR_MAX_AGE"
+	// EnvironmentVariableLogErrMaxTotalBytes is the max size of all error file archives together (e.g. `5gb`, defaults to unlimited).
+	EnvironmentVariableLogErrMaxTotalBytes = "LOG_ERR_MAX_TOTAL_BYTES"
+
+	// EnvironmentVariableLogStdoutEvents is the csv of events written to stdout (defaults to all enabled events).
+	EnvironmentVariableLogStdoutEvents = "LOG_STDOUT_EVENTS"
+	// EnvironmentVariableLogStdoutFormat is the output format for stdout (defaults to `LOG_FORMAT`).
+	EnvironmentVariableLogStdoutFormat = "LOG_STDOUT_FORMAT"
+	// EnvironmentVariableLogStderrEvents is the csv of events written to stderr (defaults to all enabled events).
+	EnvironmentVariableLogStderrEvents = "LOG_STDERR_EVENTS"
+	// EnvironmentVariableLogStderrFormat is the output format for stderr (defaults to `LOG_FORMAT`).
+	EnvironmentVariableLogStderrFormat = "LOG_STDERR_FORMAT"
+
+	// EnvironmentVariableLogOutFileEvents is the csv of events written to the output file (defaults to all enabled events).
+	EnvironmentVariableLogOutFileEvents = "LOG_OUT_FILE_EVENTS"
+	// EnvironmentVariableLogOutFileFormat is the output format for the output file (defaults to `LOG_FORMAT`).
+	EnvironmentVariableLogOutFileFormat = "LOG_OUT_FILE_FORMAT"
+	// EnvironmentVariableLogErrFileEvents is the csv of events written to the error file (defaults to all enabled events).
+	EnvironmentVariableLogErrFileEvents = "LOG_ERR_FILE_EVENTS"
+	// EnvironmentVariableLogErrFileFormat is the output format for the error file (defaults to `LOG_FORMAT`).
+	EnvironmentVariableLogErrFileFormat = "LOG_ERR_FILE_FORMAT"
+
+	// EnvironmentVariableLogAuditFile is the variable for an additional file the output stream is written to.
+	EnvironmentVariableLogAuditFile = "LOG_AUDIT_FILE"
+	// EnvironmentVariableLogAuditFileEvents is the csv of events written to the audit file (defaults to all enabled events).
+	EnvironmentVariableLogAuditFileEvents = "LOG_AUDIT_FILE_EVENTS"
+	// EnvironmentVariableLogAuditFileFormat is the output format for the audit file (defaults to `LOG_FORMAT`).
+	EnvironmentVariableLogAuditFileFormat = "LOG_AUDIT_FILE_FORMAT"
+	// EnvironmentVariableLogAuditArchiveCompress
+	EnvironmentVariableLogAuditArchiveCompress = "LOG_AUDIT_ARCHIVE_COMPRESS"
+	// EnvironmentVariableLogAuditMaxSizeBytes
+	EnvironmentVariableLogAuditMaxSizeBytes = "LOG_AUDIT_MAX_BYTES"
+	// EnvironmentVariableLogAuditMaxArchive
+	EnvironmentVariableLogAuditMaxArchive = "LOG_AUDIT_MAX_ARCHIVE"
+	// EnvironmentVariableLogAuditRotate is the rotation schedule for the audit file (`hourly`, `daily` or a duration like `30m`).
+	EnvironmentVariableLogAuditRotate = "LOG_AUDIT_ROTATE"
+	// EnvironmentVariableLogAuditRotateTZ is the time zone the audit file rotation schedule is aligned to (defaults to UTC).
+	EnvironmentVariableLogAuditRotateTZ = "LOG_AUDIT_ROTATE_TZ"
+	// EnvironmentVariableLogAuditArchiveNaming is how audit file archives are named (`index` or `timestamp`, defaults to `index`).
+	EnvironmentVariableLogAuditArchiveNaming = "LOG_AUDIT_ARCHIVE_NAMING"
+	// EnvironmentVariableLogAuditMaxAge is how long audit file archives are kept (e.g. `30d` or `72h`, defaults to forever).
+	EnvironmentVariableLogAuditMaxAge = "LOG_AUDIT_MAX_AGE"
+	// EnvironmentVariableLogAuditMaxTotalBytes is the max size of all audit file archives together (e.g. `5gb`, defaults to unlimited).
+	EnvironmentVariableLogAuditMaxTotalBytes = "LOG_AUDIT_MAX_TOTAL_BYTES"
 )
@@ -1,5 +1,7 @@
 package logger
 
+import "strings"
+
 const (
 	// EventAll is a special flag that allows all events to fire.
 	EventAll EventFlag = "all"
@@ -33,3 +35,26 @@ const (
 
 // EventFlag is a flag to enable or disable triggering handlers for an event.
 type EventFlag string
+
+const (
+	// eventFlagSeparator separates the levels of a hierarchical event flag, e.g. `web.request.start`.
+	eventFlagSeparator = "."
+	// eventFlagWildcardSuffix marks a flag that matches every event below it, e.g. `web.*`.
+	eventFlagWildcardSuffix = ".*"
+)
+
+// Subtree returns the parent of the events a wildcard flag (e.g. `web.*`) matches, and if the flag is a wildcard.
+func (ef EventFlag) Subtree() (EventFlag, bool) {
+	if strings.HasSuffix(string(ef), eventFlagWildcardSuffix) {
+		return ef[:len(ef)-len(eventFlagWildcardSuffix)], true
+	}
+	return ef, false
+}
+
+// Parent returns the flag one level up the hierarchy (`web.request` for `web.request.start`), and if there is one.
+func (ef EventFlag) Parent() (EventFlag, bool) {
+	if index := strings.LastIndex(string(ef), eventFlagSeparator); index > 0 {
+		return ef[:index], true
+	}
+	return "", false
+}
@@ -33,16 +33,25 @@ func NewEventFlagSetNone() *EventFlagSet {
 }
 
 // NewEventFlagSetFromEnvironment returns a new EventFlagSet from the environment.
+// `LOG_LEVEL` sets the minimum severity, on top of the events in `LOG_EVENTS`.
 func NewEventFlagSetFromEnvironment() *EventFlagSet {
+	var flagSet *EventFlagSet
 	envEventsFlag := os.Getenv(EnvironmentVariableLogEvents)
 	if len(envEventsFlag) > 0 {
-		return NewEventFlagSetFromCSV(envEventsFlag)
+		flagSet = NewEventFlagSetFromCSV(envEventsFlag)
+	} else {
+		flagSet = NewEventFlagSet()
 	}
-	return NewEventFlagSet()
+	if envLevel := os.Getenv(EnvironmentVariableLogLevel); len(envLevel) > 0 {
+		if severity, err := ParseSeverity(envLevel); err == nil {
+			flagSet.SetMinSeverity(severity)
+		}
+	}
+	return flagSet
 }
 
 // NewEventFlagSetFromCSV returns a new event flag set from a csv of event flags.
-// These flags are case insensitive.
+// These flags are case insensitive, and can be wildcards (`web.*`); see `IsEnabled` for how they match.
 func NewEventFlagSetFromCSV(flagCSV string) *EventFlagSet {
 	flagSet := &EventFlagSet{
 		flags: map[EventFlag]bool{},
@@ -62,9 +71,9 @@ func NewEventFlagSetFromCSV(flagCSV string) *EventFlagSet {
 
 		if strings.HasPrefix(string(parsedFlag), "-") {
 			flag := EventFlag(strings.TrimPrefix(string(parsedFlag), "-"))
-			flagSet.flags[flag] = false
+			flagSet.set(flag, false)
 		} else {
-			flagSet.flags[parsedFlag] = true
+			flagSet.set(parsedFlag, true)
 		}
 	}
 
@@ -73,20 +82,37 @@ func NewEventFlagSetFromCSV(flagCSV string) *EventFlagSet {
 
 // EventFlagSet is a set of event flags.
 type EventFlagSet struct {
-	flags map[EventFlag]bool
-	all   bool
-	none  bool
+	flags       map[EventFlag]bool
+	subtrees    map[EventFlag]bool
+	minSeverity Severity
+	all         bool
+	none        bool
 }
 
-// Enable enables an event flag.
+// Enable enables an event flag, or a subtree of flags with a wildcard (`web.*`).
 func (efs *EventFlagSet) Enable(flagValue EventFlag) {
 	efs.none = false
-	efs.flags[flagValue] = true
+	efs.set(flagValue, true)
 }
 
-// Disable disabled an event flag.
+// Disable disabled an event flag, or a subtree of flags with a wildcard (`web.*`).
 func (efs *EventFlagSet) Disable(flagValue EventFlag) {
-	efs.flags[flagValue] = false
+	efs.set(flagValue, false)
+}
+
+// set sets if a flag, or a subtree of flags, is enabled.
+func (efs *EventFlagSet) set(flagValue EventFlag, enabled bool) {
+	if subtree, isWildcard := flagValue.Subtree(); isWildcard {
+		if efs.subtrees == nil {
+			efs.subtrees = map[EventFlag]bool{}
+		}
+		efs.subtrees[subtree] = enabled
+		return
+	}
+	if efs.flags == nil {
+		efs.flags = map[EventFlag]bool{}
+	}
+	efs.flags[flagValue] = enabled
 }
 
 // EnableAll flips the `all` bit on the flag set.
@@ -95,6 +121,43 @@ func (efs *EventFlagSet) EnableAll() {
 	efs.none = false
 }
 
+// clone returns a copy of the flag set.
+func (efs *EventFlagSet) clone() *EventFlagSet {
+	if efs == nil {
+		return NewEventFlagSetNone()
+	}
+	cloned := &EventFlagSet{
+		flags:       make(map[EventFlag]bool, len(efs.flags)),
+		minSeverity: efs.minSeverity,
+		all:         efs.all,
+		none:        efs.none,
+	}
+	for flag, enabled := range efs.flags {
+		cloned.flags[flag] = enabled
+	}
+	if len(efs.subtrees) > 0 {
+		cloned.subtrees = make(map[EventFlag]bool, len(efs.subtrees))
+		for subtree, enabled := range efs.subtrees {
+			cloned.subtrees[subtree] = enabled
+		}
+	}
+	return cloned
+}
+
+// SetMinSeverity enables every event with a severity at or above a given severity (see `RegisterEventSeverity`).
+// Events that are explicitly enabled or disabled aren't affected; `SeverityNone` clears the threshold.
+func (efs *EventFlagSet) SetMinSeverity(severity Severity) {
+	if severity != SeverityNone {
+		efs.none = false
+	}
+	efs.minSeverity = severity
+}
+
+// MinSeverity returns the minimum severity, or `SeverityNone` if there isn't one.
+func (efs *EventFlagSet) MinSeverity() Severity {
+	return efs.minSeverity
+}
+
 // IsAllEnabled returns if the all bit is flipped on.
 func (efs *EventFlagSet) IsAllEnabled() bool {
 	return efs.all
@@ -112,23 +175,63 @@ func (efs *EventFlagSet) DisableAll() {
 }
 
 // IsEnabled checks to see if an event is enabled.
+// A bare flag (`web.request`) only matches itself; a wildcard (`web.*`) matches every event below its flag, but not the flag itself.
+// The most specific entry that matches an event decides if it is enabled: the event's own entry first, then the wildcards of its
+// ancestors (nearest first). So `web.*,-web.response` enables `web.request.start` but not `web.response`.
+// Events without an entry are enabled by the minimum severity (if one is set), then by the `all` bit.
 func (efs EventFlagSet) IsEnabled(flagValue EventFlag) bool {
+	enabled, _ := efs.lookup(flagValue)
+	return enabled
+}
+
+// lookup returns if an event is enabled, and if the set decided it (by an entry, the minimum severity, or the `all` or `none` bits)
+// rather than leaving it disabled by default.
+func (efs EventFlagSet) lookup(flagValue EventFlag) (enabled bool, decided bool) {
 	if efs.all {
-		// figure out if we explicitly disabled the flag.
-		if enabled, hasFlag := efs.flags[flagValue]; hasFlag && !enabled {
-			return false
+		// figure out if we explicitly disabled the flag, or it is below the minimum severity.
+		if enabled, hasFlag := efs.match(flagValue); hasFlag {
+			return enabled, true
+		}
+		if atSeverity, hasSeverity := efs.matchSeverity(flagValue); hasSeverity {
+			return atSeverity, true
 		}
-		return true
+		return true, true
 	}
 	if efs.none {
-		return false
+		return false, true
 	}
-	if efs.flags != nil {
-		if enabled, hasFlag := efs.flags[flagValue]; hasFlag {
-			return enabled
+	if enabled, hasFlag := efs.match(flagValue); hasFlag {
+		return enabled, true
+	}
+	if atSeverity, hasSeverity := efs.matchSeverity(flagValue); hasSeverity {
+		return atSeverity, true
+	}
+	return false, false
+}
+
+// matchSeverity returns if a flag is at or above the minimum severity, and if the set has one and the flag has a severity.
+func (efs EventFlagSet) matchSeverity(flagValue EventFlag) (atSeverity bool, hasSeverity bool) {
+	if efs.minSeverity == SeverityNone {
+		return false, false
+	}
+	severity, hasSeverity := EventSeverity(flagValue)
+	if !hasSeverity {
+		return false, false
+	}
+	return severity >= efs.minSeverity, true
+}
+
+// match returns the most specific entry that matches a flag, and if there is one.
+func (efs EventFlagSet) match(flagValue EventFlag) (enabled bool, hasFlag bool) {
+	if enabled, hasFlag = efs.flags[flagValue]; hasFlag {
+		return
+	}
+	for ancestor, hasParent := flagValue.Parent(); hasParent; ancestor, hasParent = ancestor.Parent() {
+		if enabled, hasFlag = efs.subtrees[ancestor]; hasFlag {
+			return
 		}
 	}
-	return false
+	return false, false
 }
 
 func (efs EventFlagSet) String() string {
@@ -149,5 +252,12 @@ func (efs EventFlagSet) String() string {
 			}
 		}
 	}
+	for key, enabled := range efs.subtrees {
+		if enabled {
+			flags = append(flags, string(key)+eventFlagWildcardSuffix)
+		} else {
+			flags = append(flags, "-"+string(key)+eventFlagWildcardSuffix)
+		}
+	}
 	return strings.Join(flags, ", ")
 }
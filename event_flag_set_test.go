@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"fmt"
 	"os"
 	"testing"
 
@@ -108,3 +109,77 @@ func TestEventFlagNoneEnableEvents(t *testing.T) {
 	flags.Enable("test_flag")
 	assert.True(flags.IsEnabled("test_flag"))
 }
+
+func TestEventFlagParentAndSubtree(t *testing.T) {
+	assert := assert.New(t)
+
+	parent, hasParent := EventWebRequestStart.Parent()
+	assert.True(hasParent)
+	assert.Equal(EventWebRequest, parent)
+	_, hasParent = EventInfo.Parent()
+	assert.False(hasParent)
+
+	subtree, isWildcard := EventFlag("web.*").Subtree()
+	assert.True(isWildcard)
+	assert.Equal(EventFlag("web"), subtree)
+	_, isWildcard = EventWebRequest.Subtree()
+	assert.False(isWildcard)
+}
+
+func TestEventFlagSetHierarchical(t *testing.T) {
+	assert := assert.New(t)
+
+	testCases := []struct {
+		CSV      string
+		Event    EventFlag
+		Expected bool
+	}{
+		{"web", "web", true},
+		{"web", EventWebRequest, false},
+		{"web", EventWebRequestStart, false},
+		{"web", "webhook", false},
+		{"web.request", EventWebRequest, true},
+		{"web.request", EventWebRequestStart, false},
+		{"web.request", EventWebRequestPostBody, false},
+		{"web.*", "web", false},
+		{"web.*", EventWebRequestPostBody, true},
+		{"web.*,-web.response", EventWebResponse, false},
+		{"web.*,-web.response", EventWebRequest, true},
+		{"web.*,-web.request.*", EventWebRequestStart, false},
+		{"web.*,-web.request.*", EventWebRequest, true},
+		{"web.*,-web.request.*,web.request.start", EventWebRequestStart, true},
+		{"web.*,-web.request.*,web.request.start", EventWebRequestPostBody, false},
+		{"web,-web.*", "web", true},
+		{"web,-web.*", EventWebRequest, false},
+		{"-web,web.*", EventWebRequest, true},
+		{"web.request.*,-web.*", EventWebRequestStart, true},
+		{"web.request.*,-web.*", EventWebRequest, false},
+		{"all,-web.*", EventWebRequest, false},
+		{"all,-web.*", EventInfo, true},
+		{"all,-web.*,web.response", EventWebResponse, true},
+	}
+
+	for _, testCase := range testCases {
+		set := NewEventFlagSetFromCSV(testCase.CSV)
+		assert.Equal(testCase.Expected, set.IsEnabled(testCase.Event), fmt.Sprintf("%s: %s", testCase.CSV, testCase.Event))
+	}
+}
+
+func TestEventFlagSetDefaultVerbosity(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(DefaultAgentVerbosity.IsEnabled(EventWebRequest))
+	assert.False(DefaultAgentVerbosity.IsEnabled(EventWebRequestStart))
+	assert.False(DefaultAgentVerbosity.IsEnabled(EventWebRequestPostBody))
+}
+
+func TestEventFlagSetEnableWildcard(t *testing.T) {
+	assert := assert.New(t)
+
+	set := NewEventFlagSet()
+	set.Enable("web.*")
+	set.Disable(EventWebRequestPostBody)
+	assert.True(set.IsEnabled(EventWebRequest))
+	assert.False(set.IsEnabled(EventWebRequestPostBody))
+	assert.Contains(set.String(), "web.*")
+}
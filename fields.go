@@ -0,0 +1,128 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+)
+
+const (
+	// FieldComponent is the key of the field child agents (see `Agent.Sub`) add to their events.
+	// The text formatter folds it into the label when labels are shown; other formatters write it like any other field.
+	FieldComponent = "component"
+	// FieldRequestID is the key of the field that carries the id of the request an event was logged for.
+	FieldRequestID = "request_id"
+)
+
+// Field is a single structured key/value pair attached to an event.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// NewFields returns a new Fields from alternating keys and values.
+// Keys that are not strings are formatted with `%v`, a trailing key without a value is given a nil value.
+func NewFields(keysAndValues ...interface{}) Fields {
+	fields := make(Fields, 0, (len(keysAndValues)+1)>>1)
+	for x := 0; x < len(keysAndValues); x += 2 {
+		var key string
+		if typed, isTyped := keysAndValues[x].(string); isTyped {
+			key = typed
+		} else {
+			key = fmt.Sprintf("%v", keysAndValues[x])
+		}
+		if x+1 < len(keysAndValues) {
+			fields = fields.Add(key, keysAndValues[x+1])
+		} else {
+			fields = fields.Add(key, nil)
+		}
+	}
+	return fields
+}
+
+// Fields is an ordered set of key/value pairs attached to an event.
+type Fields []Field
+
+// Add returns a copy of the fields with the key set to the given value.
+// If the key is already present its value is replaced in place, otherwise it is appended.
+func (f Fields) Add(key string, value interface{}) Fields {
+	copied := make(Fields, len(f), len(f)+1)
+	copy(copied, f)
+	for x := 0; x < len(copied); x++ {
+		if copied[x].Key == key {
+			copied[x].Value = value
+			return copied
+		}
+	}
+	return append(copied, Field{Key: key, Value: value})
+}
+
+// Merge returns a copy of the fields with the other fields added in order.
+func (f Fields) Merge(other Fields) Fields {
+	merged := f
+	for _, field := range other {
+		merged = merged.Add(field.Key, field.Value)
+	}
+	return merged
+}
+
+// without returns the fields without a given key; the fields are returned as is if the key isn't present.
+func (f Fields) without(key string) Fields {
+	for x := 0; x < len(f); x++ {
+		if f[x].Key == key {
+			copied := make(Fields, 0, len(f)-1)
+			copied = append(copied, f[:x]...)
+			return append(copied, f[x+1:]...)
+		}
+	}
+	return f
+}
+
+// Get returns the value for a given key, and if it was present.
+func (f Fields) Get(key string) (interface{}, bool) {
+	for x := 0; x < len(f); x++ {
+		if f[x].Key == key {
+			return f[x].Value, true
+		}
+	}
+	return nil, false
+}
+
+// Keys returns the keys of the fields in order.
+func (f Fields) Keys() []string {
+	keys := make([]string, len(f))
+	for x := 0; x < len(f); x++ {
+		keys[x] = f[x].Key
+	}
+	return keys
+}
+
+// writeTo writes the fields as space separated `key=value` pairs to a buffer.
+func (f Fields) writeTo(buffer *bytes.Buffer) {
+	for x := 0; x < len(f); x++ {
+		if x > 0 {
+			buffer.WriteRune(RuneSpace)
+		}
+		buffer.WriteString(f[x].Key)
+		buffer.WriteRune('=')
+		buffer.WriteString(fmt.Sprintf("%v", f[x].Value))
+	}
+}
+
+// String returns the fields as space separated `key=value` pairs.
+func (f Fields) String() string {
+	buffer := bytes.NewBuffer(nil)
+	f.writeTo(buffer)
+	return buffer.String()
+}
+
+// FieldsFromState returns the structured fields carried by an event's state, if any.
+// Fields are always the last element of the state; a `Fields` value that is one of the event's own args isn't returned.
+func FieldsFromState(state ...interface{}) Fields {
+	if len(state) == 0 {
+		return nil
+	}
+	if typed, isTyped := state[len(state)-1].(eventFields); isTyped {
+		return Fields(typed)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package logger
+
+import (
+	"testing"
+
+	assert "github.com/blendlabs/go-assert"
+)
+
+func TestNewFields(t *testing.T) {
+	assert := assert.New(t)
+
+	fields := NewFields("user_id", 123, "tenant", "acme", 3, "three", "dangling")
+	assert.Len(fields, 4)
+	assert.Equal([]string{"user_id", "tenant", "3", "dangling"}, fields.Keys())
+
+	value, hasValue := fields.Get("tenant")
+	assert.True(hasValue)
+	assert.Equal("acme", value)
+
+	value, hasValue = fields.Get("dangling")
+	assert.True(hasValue)
+	assert.Nil(value)
+
+	_, hasValue = fields.Get("not_a_key")
+	assert.False(hasValue)
+}
+
+func TestFieldsAdd(t *testing.T) {
+	assert := assert.New(t)
+
+	original := NewFields("foo", "bar")
+	added := original.Add("buzz", "fuzz")
+	assert.Len(original, 1)
+	assert.Len(added, 2)
+
+	replaced := added.Add("foo", "baz")
+	assert.Equal([]string{"foo", "buzz"}, replaced.Keys())
+	value, _ := replaced.Get("foo")
+	assert.Equal("baz", value)
+	value, _ = added.Get("foo")
+	assert.Equal("bar", value)
+}
+
+func TestFieldsMerge(t *testing.T) {
+	assert := assert.New(t)
+
+	merged := NewFields("a", 1, "b", 2).Merge(NewFields("b", 3, "c", 4))
+	assert.Equal([]string{"a", "b", "c"}, merged.Keys())
+	value, _ := merged.Get("b")
+	assert.Equal(3, value)
+}
+
+func TestFieldsString(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("user_id=123 tenant=acme", NewFields("user_id", 123, "tenant", "acme").String())
+	assert.Equal("", Fields(nil).String())
+}
+
+func TestFieldsFromState(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Nil(FieldsFromState())
+	assert.Nil(FieldsFromState("foo", "bar"))
+	assert.Equal(NewFields("foo", "bar"), FieldsFromState(appendFields([]interface{}{"format"}, NewFields("foo", "bar"))...))
+	assert.Nil(FieldsFromState("format", NewFields("foo", "bar")), "an arg that happens to be fields isn't the event's fields")
+}
+
+func TestNewFieldsListenerFieldsArg(t *testing.T) {
+	assert := assert.New(t)
+
+	var fields Fields
+	var state []interface{}
+	listener := NewFieldsListener(func(wr *Writer, ts TimeSource, eventFlag EventFlag, eventFields Fields, eventState ...interface{}) {
+		fields, state = eventFields, eventState
+	})
+
+	arg := NewFields("foo", "bar")
+	listener(nil, TimeNow(), EventInfo, "format", arg)
+	assert.Nil(fields)
+	assert.Equal([]interface{}{"format", arg}, state)
+
+	listener(nil, TimeNow(), EventInfo, appendFields([]interface{}{"format", arg}, NewFields("tenant", "acme"))...)
+	assert.Equal(NewFields("tenant", "acme"), fields)
+	assert.Equal([]interface{}{"format", arg}, state)
+}
@@ -0,0 +1,180 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	exception "github.com/blendlabs/go-exception"
+)
+
+const (
+	fileCompressionStagedSuffix = ".rotated"
+	fileCompressionTempSuffix   = ".gz.tmp"
+
+	isStagedFileRegexpFormat           = `^%s\.([0-9]+)\.rotated$`
+	isStagedCompressedFileRegexpFormat = `^%s\.([0-9]+)\.rotated\.gz\.tmp$`
+)
+
+// fileCompression is a rotated file waiting to be compressed into the archives.
+type fileCompression struct {
+	StagedPath string
+	RotatedAt  time.Time
+}
+
+// startCompressor starts the background compressor.
+// Rotated files left staged by a previous process are queued to be compressed, and partially compressed files are removed.
+func (fo *FileOutput) startCompressor() error {
+	staged, err := fo.recoverStagedFiles()
+	if err != nil {
+		return err
+	}
+
+	fo.compressions = make(chan fileCompression, fo.compressionBacklog)
+	fo.compressorDone = make(chan struct{})
+	go fo.compressor(fo.compressions, fo.compressorDone)
+
+	for _, compression := range staged {
+		fo.compressions <- compression
+	}
+	return nil
+}
+
+// stopCompressor stops accepting rotated files and returns a channel that is closed once the compressor finishes the backlog.
+// It must be called with syncRoot held.
+func (fo *FileOutput) stopCompressor() chan struct{} {
+	if fo.compressions == nil {
+		return nil
+	}
+	close(fo.compressions)
+	fo.compressions = nil
+	return fo.compressorDone
+}
+
+// stageCompression renames the current file to a staging path and queues it to be compressed.
+// It blocks while the compression backlog is full.
+func (fo *FileOutput) stageCompression(rotatedAt time.Time) error {
+	stamp := rotatedAt.UnixNano()
+	stagedPath := fo.makeStagedFilePath(stamp)
+	for _, err := os.Stat(stagedPath); err == nil; _, err = os.Stat(stagedPath) {
+		stamp++
+		stagedPath = fo.makeStagedFilePath(stamp)
+	}
+
+	err := os.Rename(fo.filePath, stagedPath)
+	if err != nil {
+		return err
+	}
+
+	fo.compressions <- fileCompression{StagedPath: stagedPath, RotatedAt: rotatedAt}
+	return nil
+}
+
+func (fo *FileOutput) compressor(compressions chan fileCompression, done chan struct{}) {
+	defer close(done)
+	for compression := range compressions {
+		if err := fo.compress(compression); err != nil {
+			fo.setCompressionErr(err)
+		}
+	}
+}
+
+// compress compresses a staged file next to itself, then moves it into the archives.
+// A staged file that fails to compress is left in place and retried on the next start.
+func (fo *FileOutput) compress(compression fileCompression) error {
+	tempPath := compression.StagedPath + fileCompressionTempSuffix
+	err := fo.compressFile(compression.StagedPath, tempPath)
+	if err != nil {
+		os.Remove(tempPath)
+		return exception.Wrap(err)
+	}
+
+	fo.archiveSyncRoot.Lock()
+	defer fo.archiveSyncRoot.Unlock()
+
+	paths, err := fo.getArchivedFilePaths()
+	if err != nil {
+		return exception.Wrap(err)
+	}
+	archivePath, err := fo.naming().Prepare(fo.filePath, paths, compression.RotatedAt, true, fo.fileMaxArchiveCount)
+	if err != nil {
+		return exception.Wrap(err)
+	}
+	err = os.Rename(tempPath, archivePath+archiveCompressedSuffix)
+	if err != nil {
+		return exception.Wrap(err)
+	}
+	err = os.Remove(compression.StagedPath)
+	if err != nil {
+		return exception.Wrap(err)
+	}
+	return exception.Wrap(fo.pruneArchives())
+}
+
+// recoverStagedFiles removes partially compressed files and returns the staged files, oldest first.
+func (fo *FileOutput) recoverStagedFiles() ([]fileCompression, error) {
+	base := regexp.QuoteMeta(filepath.Base(fo.filePath))
+	partialExpr, err := regexp.Compile(fmt.Sprintf(isStagedCompressedFileRegexpFormat, base))
+	if err != nil {
+		return nil, exception.Wrap(err)
+	}
+	partials, err := File.List(filepath.Dir(fo.filePath), partialExpr)
+	if err != nil {
+		return nil, exception.Wrap(err)
+	}
+	for _, partial := range partials {
+		err = os.Remove(partial)
+		if err != nil {
+			return nil, exception.Wrap(err)
+		}
+	}
+
+	stagedExpr, err := regexp.Compile(fmt.Sprintf(isStagedFileRegexpFormat, base))
+	if err != nil {
+		return nil, exception.Wrap(err)
+	}
+	stagedPaths, err := File.List(filepath.Dir(fo.filePath), stagedExpr)
+	if err != nil {
+		return nil, exception.Wrap(err)
+	}
+
+	var staged []fileCompression
+	for _, stagedPath := range stagedPaths {
+		values := stagedExpr.FindStringSubmatch(filepath.Base(stagedPath))
+		stamp, err := strconv.ParseInt(values[1], 10, 64)
+		if err != nil {
+			return nil, exception.Wrap(err)
+		}
+		rotatedAt := time.Unix(0, stamp).UTC()
+		if fo.rotationSchedule != nil && fo.rotationSchedule.Location != nil {
+			rotatedAt = rotatedAt.In(fo.rotationSchedule.Location)
+		}
+		staged = append(staged, fileCompression{StagedPath: stagedPath, RotatedAt: rotatedAt})
+	}
+	sort.SliceStable(staged, func(i, j int) bool {
+		return staged[i].RotatedAt.Before(staged[j].RotatedAt)
+	})
+	return staged, nil
+}
+
+func (fo *FileOutput) makeStagedFilePath(stamp int64) string {
+	return fmt.Sprintf("%s.%d%s", fo.filePath, stamp, fileCompressionStagedSuffix)
+}
+
+func (fo *FileOutput) setCompressionErr(err error) {
+	fo.archiveSyncRoot.Lock()
+	defer fo.archiveSyncRoot.Unlock()
+	if fo.compressionErr == nil {
+		fo.compressionErr = err
+	}
+}
+
+func (fo *FileOutput) getCompressionErr() error {
+	fo.archiveSyncRoot.Lock()
+	defer fo.archiveSyncRoot.Unlock()
+	return fo.compressionErr
+}
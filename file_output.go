@@ -8,14 +8,16 @@ import (
 	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	exception "github.com/blendlabs/go-exception"
 )
 
 const (
-	isArchiveFileRegexpFormat           = `%s\.([0-9]+)?`
-	isCompressedArchiveFileRegexpFormat = `%s\.([0-9]+)?\.gz`
+	isArchiveFileRegexpFormat           = `^%s\.([0-9]+)$`
+	isCompressedArchiveFileRegexpFormat = `^%s\.([0-9]+)\.gz$`
 
 	// Kilobyte represents the bytes in a kilobyte.
 	Kilobyte int64 = 1 << 10
@@ -37,38 +39,221 @@ const (
 
 	// FileOutputDefaultMaxArchiveFiles is the default number of archive files (10).
 	FileOutputDefaultMaxArchiveFiles int64 = 10
+
+	// FileOutputDefaultCompressionBacklog is the default number of rotated files that can be waiting to be compressed (4).
+	// Rotations block once the backlog is full.
+	FileOutputDefaultCompressionBacklog = 4
 )
 
-// NewFileOutput creates a new file writer.
-func NewFileOutput(filePath string, shouldCompressArchivedFiles bool, fileMaxSizeBytes, fileMaxArchiveCount int64) (*FileOutput, error) {
-	file, err := File.CreateOrOpen(filePath)
-	if err != nil {
-		return nil, err
+// FileOutputOption is an optional setting for a FileOutput.
+type FileOutputOption func(*FileOutput) error
+
+// FileOutputRotateEvery rotates files on a schedule with a given interval, aligned to wall clock boundaries in UTC.
+// Scheduled rotation combines with the size limit; whichever is reached first rotates the file.
+func FileOutputRotateEvery(interval time.Duration) FileOutputOption {
+	return func(fo *FileOutput) error {
+		if interval <= 0 {
+			return exception.Newf("Invalid rotation interval `%v`", interval)
+		}
+		var location *time.Location
+		if fo.rotationSchedule != nil {
+			location = fo.rotationSchedule.Location
+		}
+		fo.rotationSchedule = NewRotationSchedule(interval, location)
+		return nil
 	}
+}
 
-	var regex *regexp.Regexp
-	if shouldCompressArchivedFiles {
-		regex, err = createIsCompressedArchiveFileRegexp(filePath)
-	} else {
-		regex, err = createIsArchivedFileRegexp(filePath)
+// FileOutputRotateHourly rotates files at the top of every hour.
+func FileOutputRotateHourly() FileOutputOption {
+	return FileOutputRotateEvery(RotationHourly)
+}
+
+// FileOutputRotateDaily rotates files at midnight.
+func FileOutputRotateDaily() FileOutputOption {
+	return FileOutputRotateEvery(RotationDaily)
+}
+
+// FileOutputRotationLocation sets the time zone rotation boundaries are aligned to.
+// It should be given after the rotation interval.
+func FileOutputRotationLocation(location *time.Location) FileOutputOption {
+	return func(fo *FileOutput) error {
+		if fo.rotationSchedule == nil {
+			return exception.New("A rotation interval is required to set a rotation location")
+		}
+		fo.rotationSchedule.Location = location
+		return nil
+	}
+}
+
+// FileOutputRotationSchedule sets the rotation schedule.
+func FileOutputRotationSchedule(schedule *RotationSchedule) FileOutputOption {
+	return func(fo *FileOutput) error {
+		if schedule != nil && schedule.Interval <= 0 {
+			return exception.Newf("Invalid rotation interval `%v`", schedule.Interval)
+		}
+		fo.rotationSchedule = schedule
+		return nil
+	}
+}
+
+// FileOutputRotationFromEnvironment sets the rotation schedule from the given environment variable names.
+// The schedule variable is `hourly`, `daily` or a duration, the location variable is an IANA time zone name.
+// If the schedule variable is unset the option does nothing.
+func FileOutputRotationFromEnvironment(scheduleVar, locationVar string) FileOutputOption {
+	return func(fo *FileOutput) error {
+		schedule := os.Getenv(scheduleVar)
+		if len(schedule) == 0 {
+			return nil
+		}
+		rotationSchedule, err := ParseRotationSchedule(schedule, os.Getenv(locationVar))
+		if err != nil {
+			return exception.Wrap(err)
+		}
+		fo.rotationSchedule = rotationSchedule
+		return nil
+	}
+}
+
+// FileOutputArchiveNaming sets the strategy archives are named with.
+// The default names archives with an index (`app.log.1`, `app.log.2` ...).
+func FileOutputArchiveNaming(naming ArchiveNaming) FileOutputOption {
+	return func(fo *FileOutput) error {
+		if naming == nil {
+			return exception.New("An archive naming strategy is required")
+		}
+		fo.archiveNaming = naming
+		return nil
+	}
+}
+
+// FileOutputArchiveNamingFromEnvironment sets the archive naming strategy from the given environment variable name (`index` or `timestamp`).
+// If the variable is unset the option does nothing.
+func FileOutputArchiveNamingFromEnvironment(namingVar string) FileOutputOption {
+	return func(fo *FileOutput) error {
+		name := os.Getenv(namingVar)
+		if len(name) == 0 {
+			return nil
+		}
+		naming, err := ParseArchiveNaming(name)
+		if err != nil {
+			return err
+		}
+		fo.archiveNaming = naming
+		return nil
+	}
+}
+
+// FileOutputMaxArchiveAge removes archives older than a given age.
+func FileOutputMaxArchiveAge(maxAge time.Duration) FileOutputOption {
+	return func(fo *FileOutput) error {
+		fo.archiveMaxAge = maxAge
+		return nil
+	}
+}
+
+// FileOutputMaxArchiveTotalBytes removes the oldest archives once all of the archives together exceed a given size.
+func FileOutputMaxArchiveTotalBytes(maxTotalBytes int64) FileOutputOption {
+	return func(fo *FileOutput) error {
+		fo.archiveMaxTotalBytes = maxTotalBytes
+		return nil
+	}
+}
+
+// FileOutputRetentionFromEnvironment sets the archive retention from the given environment variable names.
+// The age variable is a duration with an optional `d` unit for days (e.g. `30d`), the total size variable is a file size (e.g. `5gb`).
+// Unset variables leave retention unlimited.
+func FileOutputRetentionFromEnvironment(maxAgeVar, maxTotalBytesVar string) FileOutputOption {
+	return func(fo *FileOutput) error {
+		if maxAge := os.Getenv(maxAgeVar); len(maxAge) > 0 {
+			parsed, err := parseArchiveAge(maxAge)
+			if err != nil {
+				return err
+			}
+			fo.archiveMaxAge = parsed
+		}
+		fo.archiveMaxTotalBytes = File.ParseSize(os.Getenv(maxTotalBytesVar), fo.archiveMaxTotalBytes)
+		return nil
+	}
+}
+
+// FileOutputCompressionBacklog sets the number of rotated files that can be waiting to be compressed before rotations block.
+func FileOutputCompressionBacklog(backlog int) FileOutputOption {
+	return func(fo *FileOutput) error {
+		if backlog < 1 {
+			return exception.Newf("Invalid compression backlog `%d`", backlog)
+		}
+		fo.compressionBacklog = backlog
+		return nil
+	}
+}
+
+// FileOutputTimeSource sets the time source used for scheduled rotation.
+func FileOutputTimeSource(timeSource TimeSource) FileOutputOption {
+	return func(fo *FileOutput) error {
+		fo.timeSource = timeSource
+		return nil
 	}
+}
+
+// NewFileOutput creates a new file writer.
+func NewFileOutput(filePath string, shouldCompressArchivedFiles bool, fileMaxSizeBytes, fileMaxArchiveCount int64, options ...FileOutputOption) (*FileOutput, error) {
+	file, err := File.CreateOrOpen(filePath)
 	if err != nil {
 		return nil, err
 	}
 
-	return &FileOutput{
+	fo := &FileOutput{
 		filePath:                    filePath,
 		file:                        file,
 		syncRoot:                    &sync.Mutex{},
-		isArchiveFileRegexp:         regex,
 		shouldCompressArchivedFiles: shouldCompressArchivedFiles,
 		fileMaxSizeBytes:            fileMaxSizeBytes,
 		fileMaxArchiveCount:         fileMaxArchiveCount,
-	}, nil
+		timeSource:                  SystemClock,
+		archiveNaming:               IndexArchiveNaming{},
+		archiveSyncRoot:             &sync.Mutex{},
+		compressionBacklog:          FileOutputDefaultCompressionBacklog,
+	}
+	for _, option := range options {
+		if err = option(fo); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	fo.isArchiveFileRegexp, err = fo.archiveNaming.Regexp(filePath, shouldCompressArchivedFiles)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	err = fo.pruneArchives()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if fo.rotationSchedule != nil {
+		err = fo.scheduleRotation()
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	if shouldCompressArchivedFiles {
+		err = fo.startCompressor()
+		if err != nil {
+			fo.Close()
+			return nil, err
+		}
+	}
+	return fo, nil
 }
 
 // NewFileOutputFromEnvironment creates a new FileOutput from the given environment variable names.`
-func NewFileOutputFromEnvironment(pathVar, shouldCompressVar, maxSizeVar, maxArchiveVar string) (*FileOutput, error) {
+func NewFileOutputFromEnvironment(pathVar, shouldCompressVar, maxSizeVar, maxArchiveVar string, options ...FileOutputOption) (*FileOutput, error) {
 	filePath := os.Getenv(pathVar)
 	if len(filePath) == 0 {
 		return nil, fmt.Errorf("Environment Variable `%s` required", pathVar)
@@ -77,7 +262,7 @@ func NewFileOutputFromEnvironment(pathVar, shouldCompressVar, maxSizeVar, maxArc
 	shouldCompress := envFlagIsSet(shouldCompressVar, false)
 	maxFileSize := File.ParseSize(os.Getenv(maxSizeVar), FileOutputDefaultFileSize)
 	maxArchive := envFlagInt64(maxArchiveVar, FileOutputDefaultMaxArchiveFiles)
-	return NewFileOutput(filePath, shouldCompress, maxFileSize, maxArchive)
+	return NewFileOutput(filePath, shouldCompress, maxFileSize, maxArchive, options...)
 }
 
 // NewFileOutputWithDefaults returns a new file writer with defaults.
@@ -96,7 +281,22 @@ type FileOutput struct {
 	fileMaxSizeBytes    int64
 	fileMaxArchiveCount int64
 
+	archiveMaxAge        time.Duration
+	archiveMaxTotalBytes int64
+
+	timeSource       TimeSource
+	rotationSchedule *RotationSchedule
+	nextRotation     time.Time
+
+	archiveNaming       ArchiveNaming
 	isArchiveFileRegexp *regexp.Regexp
+
+	// archiveSyncRoot guards the archives, which the compressor changes outside of syncRoot.
+	archiveSyncRoot    *sync.Mutex
+	compressionBacklog int
+	compressions       chan fileCompression
+	compressorDone     chan struct{}
+	compressionErr     error
 }
 
 // Write writes to the file.
@@ -104,48 +304,122 @@ func (fo *FileOutput) Write(buffer []byte) (int, error) {
 	fo.syncRoot.Lock()
 	defer fo.syncRoot.Unlock()
 
-	if fo.fileMaxSizeBytes > 0 {
+	if fo.fileMaxSizeBytes > 0 || fo.rotationSchedule != nil {
 		stat, err := fo.file.Stat()
 		if err != nil {
 			return 0, exception.New(err)
 		}
 
-		if stat.Size() > fo.fileMaxSizeBytes {
-			err = fo.rotateFile()
+		if fo.shouldRotate(stat.Size()) {
+			err = fo.rotateFile(fo.rotatedAt())
 			if err != nil {
 				return 0, exception.New(err)
 			}
 		}
+		if fo.rotationSchedule != nil && !fo.now().Before(fo.nextRotation) {
+			fo.nextRotation = fo.rotationSchedule.Next(fo.now())
+		}
 	}
 
 	written, err := fo.file.Write(buffer)
 	return written, exception.Wrap(err)
 }
 
+// Reopen reopens the file at the file path, for use after the file has been moved by an external tool (e.g. logrotate).
+// Writes are blocked while the file is reopened, and keep going to the previous file if it can't be reopened.
+func (fo *FileOutput) Reopen() error {
+	fo.syncRoot.Lock()
+	defer fo.syncRoot.Unlock()
+
+	if fo.file == nil {
+		return exception.New("File output is closed")
+	}
+
+	file, err := File.CreateOrOpen(fo.filePath)
+	if err != nil {
+		return exception.Wrap(err)
+	}
+	previous := fo.file
+	fo.file = file
+
+	if fo.rotationSchedule != nil {
+		err = fo.scheduleRotation()
+		if err != nil {
+			previous.Close()
+			return err
+		}
+	}
+	return exception.Wrap(previous.Close())
+}
+
 // Close closes the stream.
+// It waits for rotated files to finish compressing, and returns the first compression error if there was one.
 func (fo *FileOutput) Close() error {
+	fo.syncRoot.Lock()
+	var err error
 	if fo.file != nil {
-		err := fo.file.Close()
+		err = fo.file.Close()
 		fo.file = nil
-		return err
 	}
-	return nil
+	compressorDone := fo.stopCompressor()
+	fo.syncRoot.Unlock()
+
+	if compressorDone != nil {
+		<-compressorDone
+		if compressionErr := fo.getCompressionErr(); compressionErr != nil && err == nil {
+			err = compressionErr
+		}
+	}
+	return err
 }
 
-func (fo *FileOutput) makeArchiveFilePath(filePath string, index int64) string {
-	return fmt.Sprintf("%s.%d", filePath, index)
+// shouldRotate returns if the file should be rotated, either because it exceeds the max size or a rotation boundary has passed.
+// Empty files are never rotated.
+func (fo *FileOutput) shouldRotate(size int64) bool {
+	if size == 0 {
+		return false
+	}
+	if fo.fileMaxSizeBytes > 0 && size > fo.fileMaxSizeBytes {
+		return true
+	}
+	return fo.rotationSchedule != nil && !fo.now().Before(fo.nextRotation)
 }
 
-func (fo *FileOutput) makeCompressedArchiveFilePath(filePath string, index int64) string {
-	return fmt.Sprintf("%s.%d.gz", filePath, index)
+// scheduleRotation sets the first rotation boundary.
+// A non-empty file is scheduled from when it was last written to, so a file left over from a previous period rotates on the first write.
+func (fo *FileOutput) scheduleRotation() error {
+	stat, err := fo.file.Stat()
+	if err != nil {
+		return exception.Wrap(err)
+	}
+	if stat.Size() > 0 {
+		fo.nextRotation = fo.rotationSchedule.Next(stat.ModTime())
+	} else {
+		fo.nextRotation = fo.rotationSchedule.Next(fo.now())
+	}
+	return nil
 }
 
-func (fo *FileOutput) makeTempArchiveFilePath(filePath string, index int64) string {
-	return fmt.Sprintf("%s.%d.tmp", filePath, index)
+// rotatedAt returns the time a rotation happens at; the rotation boundary if the rotation is scheduled, otherwise the current time.
+func (fo *FileOutput) rotatedAt() time.Time {
+	now := fo.now()
+	if fo.rotationSchedule == nil {
+		return now
+	}
+	if !now.Before(fo.nextRotation) {
+		now = fo.nextRotation
+	}
+	if fo.rotationSchedule.Location != nil {
+		return now.In(fo.rotationSchedule.Location)
+	}
+	return now
 }
 
-func (fo *FileOutput) makeTempCompressedArchiveFilePath(filePath string, index int64) string {
-	return fmt.Sprintf("%s.%d.gz.tmp", filePath, index)
+func (fo *FileOutput) now() time.Time {
+	if fo.timeSource == nil {
+		return time.Now().UTC()
+	}
+	return fo.timeSource.UTCNow()
 }
 
 func (fo *FileOutput) compressFile(inFilePath, outFilePath string) error {
@@ -159,26 +433,24 @@ func (fo *FileOutput) compressFile(inFilePath, outFilePath string) error {
 	if err != nil {
 		return err
 	}
-	defer outFile.Close()
 
+	// the compressed file has to be complete and on disk before the file it replaces is removed.
 	gzw := gzip.NewWriter(outFile)
-	defer gzw.Close()
-
 	_, err = io.Copy(gzw, inFile)
-	if err != nil {
-		return err
+	if err == nil {
+		err = gzw.Close()
 	}
-	return gzw.Flush()
+	if err == nil {
+		err = outFile.Sync()
+	}
+	if closeErr := outFile.Close(); err == nil {
+		err = closeErr
+	}
+	return err
 }
 
 func (fo *FileOutput) extractArchivedFileIndex(filePath string) (int64, error) {
-	filePathBase := filepath.Base(filePath)
-	values := fo.isArchiveFileRegexp.FindStringSubmatch(filePathBase)
-	if len(values) > 1 {
-		value, err := strconv.ParseInt(values[1], 10, 32)
-		return value, exception.Wrap(err)
-	}
-	return 0, exception.Newf("Cannot extract file index from `%s`", filePathBase)
+	return extractArchiveIndex(fo.isArchiveFileRegexp, filePath)
 }
 
 func (fo *FileOutput) isArchivedFile(filePath string) bool {
@@ -206,94 +478,135 @@ func (fo *FileOutput) getMaxArchivedFileIndex(paths []string) (int64, error) {
 }
 
 func (fo *FileOutput) shiftArchivedFiles(paths []string) error {
-	var index int64
-	var err error
-
-	intermediatePaths := make(map[string]string)
-	var tempPath, finalPath string
+	return shiftIndexedArchives(fo.filePath, fo.isArchiveFileRegexp, paths, fo.shouldCompressArchivedFiles, fo.fileMaxArchiveCount)
+}
 
-	for _, path := range paths {
-		index, err = fo.extractArchivedFileIndex(path)
-		if err != nil {
-			return err
-		}
-		if fo.shouldCompressArchivedFiles {
-			tempPath = fo.makeTempCompressedArchiveFilePath(fo.filePath, index+1)
-			finalPath = fo.makeCompressedArchiveFilePath(fo.filePath, index+1)
-		} else {
-			tempPath = fo.makeTempArchiveFilePath(fo.filePath, index+1)
-			finalPath = fo.makeArchiveFilePath(fo.filePath, index+1)
-		}
+func (fo *FileOutput) naming() ArchiveNaming {
+	if fo.archiveNaming == nil {
+		return IndexArchiveNaming{}
+	}
+	return fo.archiveNaming
+}
 
-		if fo.fileMaxArchiveCount > 0 {
-			if index+1 <= fo.fileMaxArchiveCount {
-				err = os.Rename(path, tempPath)
-				intermediatePaths[tempPath] = finalPath
-			} else {
-				err = os.Remove(path)
-			}
-		} else {
-			err = os.Rename(path, tempPath)
-			intermediatePaths[tempPath] = finalPath
-		}
-		if err != nil {
-			return err
-		}
+func (fo *FileOutput) rotateFile(rotatedAt time.Time) error {
+	err := fo.file.Close()
+	if err != nil {
+		return err
 	}
 
-	for from, to := range intermediatePaths {
-		err = os.Rename(from, to)
-		if err != nil {
-			return err
-		}
+	if fo.shouldCompressArchivedFiles && fo.compressions != nil {
+		err = fo.stageCompression(rotatedAt)
+	} else {
+		err = fo.archiveFile(fo.filePath, rotatedAt)
+	}
+	if err != nil {
+		return err
 	}
 
+	file, err := os.Create(fo.filePath)
+	if err != nil {
+		return err
+	}
+	fo.file = file
 	return nil
 }
 
-func (fo *FileOutput) rotateFile() error {
-	var err error
-
-	paths, err := fo.getArchivedFilePaths()
-	if err != nil {
-		return err
+// archiveFile moves a file into the archives (compressing it if required) and prunes the archives.
+func (fo *FileOutput) archiveFile(filePath string, rotatedAt time.Time) error {
+	if fo.archiveSyncRoot != nil {
+		fo.archiveSyncRoot.Lock()
+		defer fo.archiveSyncRoot.Unlock()
 	}
 
-	err = fo.shiftArchivedFiles(paths)
+	paths, err := fo.getArchivedFilePaths()
 	if err != nil {
 		return err
 	}
 
-	err = fo.file.Close()
+	archivePath, err := fo.naming().Prepare(fo.filePath, paths, rotatedAt, fo.shouldCompressArchivedFiles, fo.fileMaxArchiveCount)
 	if err != nil {
 		return err
 	}
 
 	if fo.shouldCompressArchivedFiles {
-		err = fo.compressFile(fo.filePath, fo.makeCompressedArchiveFilePath(fo.filePath, 1))
-		if err != nil {
-			return err
-		}
-		err = os.Remove(fo.filePath)
+		err = fo.compressFile(filePath, archivePath+archiveCompressedSuffix)
 		if err != nil {
+			os.Remove(archivePath + archiveCompressedSuffix)
 			return err
 		}
+		err = os.Remove(filePath)
 	} else {
-		err = os.Rename(fo.filePath, fo.makeArchiveFilePath(fo.filePath, 1))
+		err = os.Rename(filePath, archivePath)
+	}
+	if err != nil {
+		return err
 	}
+	return fo.pruneArchives()
+}
 
-	file, err := os.Create(fo.filePath)
+// pruneArchives removes archives that are past the max archive count, older than the max age, or past the max total size, oldest first.
+// Archive age is measured from the archive's modification time.
+func (fo *FileOutput) pruneArchives() error {
+	if fo.fileMaxArchiveCount <= 0 && fo.archiveMaxAge <= 0 && fo.archiveMaxTotalBytes <= 0 {
+		return nil
+	}
+
+	paths, err := fo.getArchivedFilePaths()
 	if err != nil {
 		return err
 	}
-	fo.file = file
+
+	sorted, err := fo.naming().Sort(fo.filePath, paths, fo.shouldCompressArchivedFiles)
+	if err != nil {
+		return err
+	}
+
+	now := fo.now()
+	var kept, totalBytes int64
+	for x := len(sorted) - 1; x >= 0; x-- {
+		stat, err := os.Stat(sorted[x])
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return exception.Wrap(err)
+		}
+
+		kept++
+		totalBytes += stat.Size()
+		if (fo.fileMaxArchiveCount > 0 && kept > fo.fileMaxArchiveCount) ||
+			(fo.archiveMaxAge > 0 && now.Sub(stat.ModTime()) > fo.archiveMaxAge) ||
+			(fo.archiveMaxTotalBytes > 0 && totalBytes > fo.archiveMaxTotalBytes) {
+			err = os.Remove(sorted[x])
+			if err != nil {
+				return exception.Wrap(err)
+			}
+		}
+	}
 	return nil
 }
 
+// parseArchiveAge parses a duration, allowing a `d` unit for days.
+func parseArchiveAge(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.ParseFloat(strings.TrimSuffix(value, "d"), 64)
+		if err != nil {
+			return 0, exception.Newf("Invalid archive age `%s`", value)
+		}
+		return time.Duration(days * float64(24*time.Hour)), nil
+	}
+	age, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, exception.Newf("Invalid archive age `%s`", value)
+	}
+	return age, nil
+}
+
 func createIsArchivedFileRegexp(filePath string) (*regexp.Regexp, error) {
-	return regexp.Compile(fmt.Sprintf(isArchiveFileRegexpFormat, filepath.Base(filePath)))
+	return regexp.Compile(fmt.Sprintf(isArchiveFileRegexpFormat, regexp.QuoteMeta(filepath.Base(filePath))))
 }
 
 func createIsCompressedArchiveFileRegexp(filePath string) (*regexp.Regexp, error) {
-	return regexp.Compile(fmt.Sprintf(isCompressedArchiveFileRegexpFormat, filepath.Base(filePath)))
+	return regexp.Compile(fmt.Sprintf(isCompressedArchiveFileRegexpFormat, regexp.QuoteMeta(filepath.Base(filePath))))
 }
@@ -1,8 +1,12 @@
 package logger
 
 import (
+	"compress/gzip"
 	"fmt"
+	"io/ioutil"
+	"regexp"
 	"testing"
+	"time"
 
 	"os"
 
@@ -93,6 +97,7 @@ func TestNewFileOutputCompressedArchived(t *testing.T) {
 		assert.Nil(err)
 		total += written
 	}
+	assert.Nil(archived.Close())
 
 	files, err := archived.getArchivedFilePaths()
 	assert.Nil(err)
@@ -261,3 +266,345 @@ func TestFileOutputExtractArchivedFileIndex(t *testing.T) {
 	assert.NotNil(err)
 	assert.Equal(0, index)
 }
+
+type testClock struct {
+	now time.Time
+}
+
+func (tc *testClock) UTCNow() time.Time {
+	return tc.now.UTC()
+}
+
+func TestFileOutputRotateHourly(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+
+	clock := &testClock{now: time.Date(2017, 03, 04, 14, 25, 0, 0, time.UTC)}
+	tempFile := filepath.Join(tempDir, "app.log")
+	fo, err := NewFileOutput(tempFile, false, FileOutputUnlimitedSize, FileOutputUnlimitedArchiveFiles, FileOutputRotateHourly(), FileOutputTimeSource(clock))
+	assert.Nil(err)
+	defer fo.Close()
+	assert.Equal(time.Date(2017, 03, 04, 15, 0, 0, 0, time.UTC), fo.nextRotation)
+
+	_, err = fo.Write([]byte("first\n"))
+	assert.Nil(err)
+
+	clock.now = time.Date(2017, 03, 04, 14, 59, 59, 0, time.UTC)
+	_, err = fo.Write([]byte("second\n"))
+	assert.Nil(err)
+	_, err = os.Stat(tempFile + ".1")
+	assert.True(os.IsNotExist(err))
+
+	clock.now = time.Date(2017, 03, 04, 15, 0, 1, 0, time.UTC)
+	_, err = fo.Write([]byte("third\n"))
+	assert.Nil(err)
+	assert.Equal(time.Date(2017, 03, 04, 16, 0, 0, 0, time.UTC), fo.nextRotation)
+
+	archived, err := ioutil.ReadFile(tempFile + ".1")
+	assert.Nil(err)
+	assert.Equal("first\nsecond\n", string(archived))
+	current, err := ioutil.ReadFile(tempFile)
+	assert.Nil(err)
+	assert.Equal("third\n", string(current))
+}
+
+func TestFileOutputRotateSkipsEmptyFiles(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+
+	clock := &testClock{now: time.Date(2017, 03, 04, 14, 25, 0, 0, time.UTC)}
+	tempFile := filepath.Join(tempDir, "app.log")
+	fo, err := NewFileOutput(tempFile, false, FileOutputUnlimitedSize, FileOutputUnlimitedArchiveFiles, FileOutputRotateHourly(), FileOutputTimeSource(clock))
+	assert.Nil(err)
+	defer fo.Close()
+
+	clock.now = time.Date(2017, 03, 04, 17, 30, 0, 0, time.UTC)
+	_, err = fo.Write([]byte("first\n"))
+	assert.Nil(err)
+	assert.Equal(time.Date(2017, 03, 04, 18, 0, 0, 0, time.UTC), fo.nextRotation)
+
+	_, err = os.Stat(tempFile + ".1")
+	assert.True(os.IsNotExist(err))
+}
+
+func TestFileOutputRotateBySizeAndSchedule(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+
+	clock := &testClock{now: time.Date(2017, 03, 04, 14, 25, 0, 0, time.UTC)}
+	tempFile := filepath.Join(tempDir, "app.log")
+	fo, err := NewFileOutput(tempFile, false, 8, FileOutputUnlimitedArchiveFiles, FileOutputRotateDaily(), FileOutputTimeSource(clock))
+	assert.Nil(err)
+	defer fo.Close()
+
+	_, err = fo.Write([]byte("0123456789\n"))
+	assert.Nil(err)
+	_, err = fo.Write([]byte("size\n"))
+	assert.Nil(err)
+
+	clock.now = time.Date(2017, 03, 05, 0, 0, 0, 0, time.UTC)
+	_, err = fo.Write([]byte("day\n"))
+	assert.Nil(err)
+
+	older, err := ioutil.ReadFile(tempFile + ".2")
+	assert.Nil(err)
+	assert.Equal("0123456789\n", string(older))
+	newer, err := ioutil.ReadFile(tempFile + ".1")
+	assert.Nil(err)
+	assert.Equal("size\n", string(newer))
+	current, err := ioutil.ReadFile(tempFile)
+	assert.Nil(err)
+	assert.Equal("day\n", string(current))
+}
+
+func TestFileOutputRotationLocation(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+
+	clock := &testClock{now: time.Date(2017, 03, 04, 6, 0, 0, 0, time.UTC)}
+	location := time.FixedZone("UTC-8", -8*60*60)
+	fo, err := NewFileOutput(filepath.Join(tempDir, "app.log"), false, FileOutputUnlimitedSize, FileOutputUnlimitedArchiveFiles,
+		FileOutputRotateDaily(), FileOutputRotationLocation(location), FileOutputTimeSource(clock))
+	assert.Nil(err)
+	defer fo.Close()
+	assert.True(fo.nextRotation.Equal(time.Date(2017, 03, 04, 8, 0, 0, 0, time.UTC)))
+
+	_, err = NewFileOutput(filepath.Join(tempDir, "other.log"), false, FileOutputUnlimitedSize, FileOutputUnlimitedArchiveFiles, FileOutputRotationLocation(location))
+	assert.NotNil(err)
+}
+
+func TestFileOutputRotationFromEnvironment(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+
+	os.Setenv(EnvironmentVariableLogOutRotate, "30m")
+	defer os.Unsetenv(EnvironmentVariableLogOutRotate)
+
+	fo, err := NewFileOutput(filepath.Join(tempDir, "app.log"), false, FileOutputUnlimitedSize, FileOutputUnlimitedArchiveFiles,
+		FileOutputRotationFromEnvironment(EnvironmentVariableLogOutRotate, EnvironmentVariableLogOutRotateTZ))
+	assert.Nil(err)
+	defer fo.Close()
+	assert.NotNil(fo.rotationSchedule)
+	assert.Equal(30*time.Minute, fo.rotationSchedule.Interval)
+
+	os.Setenv(EnvironmentVariableLogOutRotate, "sometimes")
+	_, err = NewFileOutput(filepath.Join(tempDir, "other.log"), false, FileOutputUnlimitedSize, FileOutputUnlimitedArchiveFiles,
+		FileOutputRotationFromEnvironment(EnvironmentVariableLogOutRotate, EnvironmentVariableLogOutRotateTZ))
+	assert.NotNil(err)
+}
+
+func TestFileOutputTimestampArchiveNaming(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+
+	clock := &testClock{now: time.Date(2017, 03, 04, 14, 25, 0, 0, time.UTC)}
+	tempFile := filepath.Join(tempDir, "app.log")
+	fo, err := NewFileOutput(tempFile, true, FileOutputUnlimitedSize, 2,
+		FileOutputRotateHourly(), FileOutputArchiveNaming(TimestampArchiveNaming{}), FileOutputTimeSource(clock))
+	assert.Nil(err)
+	defer fo.Close()
+
+	for hour := 14; hour < 18; hour++ {
+		clock.now = time.Date(2017, 03, 04, hour, 30, 0, 0, time.UTC)
+		_, err = fo.Write([]byte("line\n"))
+		assert.Nil(err)
+	}
+	assert.Nil(fo.Close())
+
+	archives, err := fo.getArchivedFilePaths()
+	assert.Nil(err)
+	assert.Equal([]string{
+		tempFile + ".2017-03-04T16-00-00.gz",
+		tempFile + ".2017-03-04T17-00-00.gz",
+	}, archives)
+}
+
+func TestFileOutputPruneArchivesByAgeOnStartup(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+
+	now := time.Date(2017, 03, 04, 14, 25, 0, 0, time.UTC)
+	tempFile := filepath.Join(tempDir, "app.log")
+	for index, age := range []time.Duration{time.Hour, 10 * 24 * time.Hour, 40 * 24 * time.Hour} {
+		archive := fmt.Sprintf("%s.%d", tempFile, index+1)
+		assert.Nil(File.CreateAndClose(archive))
+		assert.Nil(os.Chtimes(archive, now.Add(-age), now.Add(-age)))
+	}
+
+	fo, err := NewFileOutput(tempFile, false, FileOutputUnlimitedSize, FileOutputUnlimitedArchiveFiles,
+		FileOutputMaxArchiveAge(30*24*time.Hour), FileOutputTimeSource(&testClock{now: now}))
+	assert.Nil(err)
+	defer fo.Close()
+
+	archives, err := fo.getArchivedFilePaths()
+	assert.Nil(err)
+	assert.Equal([]string{tempFile + ".1", tempFile + ".2"}, archives)
+}
+
+func TestFileOutputPruneArchivesByTotalSize(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+
+	tempFile := filepath.Join(tempDir, "app.log")
+	fo, err := NewFileOutput(tempFile, false, 8, FileOutputUnlimitedArchiveFiles, FileOutputMaxArchiveTotalBytes(25))
+	assert.Nil(err)
+	defer fo.Close()
+
+	for x := 0; x < 4; x++ {
+		_, err = fo.Write([]byte("0123456789\n"))
+		assert.Nil(err)
+	}
+
+	archives, err := fo.getArchivedFilePaths()
+	assert.Nil(err)
+	assert.Equal([]string{tempFile + ".1", tempFile + ".2"}, archives)
+}
+
+func TestFileOutputRetentionFromEnvironment(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+
+	os.Setenv(EnvironmentVariableLogOutMaxAge, "30d")
+	defer os.Unsetenv(EnvironmentVariableLogOutMaxAge)
+	os.Setenv(EnvironmentVariableLogOutMaxTotalBytes, "5gb")
+	defer os.Unsetenv(EnvironmentVariableLogOutMaxTotalBytes)
+
+	fo, err := NewFileOutput(filepath.Join(tempDir, "app.log"), false, FileOutputUnlimitedSize, FileOutputUnlimitedArchiveFiles,
+		FileOutputRetentionFromEnvironment(EnvironmentVariableLogOutMaxAge, EnvironmentVariableLogOutMaxTotalBytes))
+	assert.Nil(err)
+	defer fo.Close()
+	assert.Equal(30*24*time.Hour, fo.archiveMaxAge)
+	assert.Equal(5*Gigabyte, fo.archiveMaxTotalBytes)
+
+	os.Setenv(EnvironmentVariableLogOutMaxAge, "a while")
+	_, err = NewFileOutput(filepath.Join(tempDir, "other.log"), false, FileOutputUnlimitedSize, FileOutputUnlimitedArchiveFiles,
+		FileOutputRetentionFromEnvironment(EnvironmentVariableLogOutMaxAge, EnvironmentVariableLogOutMaxTotalBytes))
+	assert.NotNil(err)
+}
+
+func TestFileOutputCompressesInBackground(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+
+	tempFile := filepath.Join(tempDir, "app.log")
+	fo, err := NewFileOutput(tempFile, true, 8, FileOutputUnlimitedArchiveFiles, FileOutputCompressionBacklog(1))
+	assert.Nil(err)
+
+	for _, line := range []string{"first line\n", "second line\n", "third line\n", "fourth line\n"} {
+		_, err = fo.Write([]byte(line))
+		assert.Nil(err)
+	}
+	assert.Nil(fo.Close())
+
+	archives, err := File.List(tempDir, regexp.MustCompile(`^app\.log\.`))
+	assert.Nil(err)
+	assert.Equal([]string{tempFile + ".1.gz", tempFile + ".2.gz", tempFile + ".3.gz"}, archives)
+
+	for index, expected := range []string{"third line\n", "second line\n", "first line\n"} {
+		contents, err := readCompressedFile(fmt.Sprintf("%s.%d.gz", tempFile, index+1))
+		assert.Nil(err)
+		assert.Equal(expected, contents)
+	}
+}
+
+func TestFileOutputRecoversStagedFiles(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+
+	tempFile := filepath.Join(tempDir, "app.log")
+	staged := fmt.Sprintf("%s.%d.rotated", tempFile, time.Date(2017, 03, 04, 15, 0, 0, 0, time.UTC).UnixNano())
+	assert.Nil(ioutil.WriteFile(staged, []byte("staged\n"), 0666))
+	assert.Nil(ioutil.WriteFile(staged+".gz.tmp", []byte("partial"), 0666))
+
+	fo, err := NewFileOutput(tempFile, true, FileOutputUnlimitedSize, FileOutputUnlimitedArchiveFiles, FileOutputArchiveNaming(TimestampArchiveNaming{}))
+	assert.Nil(err)
+	assert.Nil(fo.Close())
+
+	archives, err := File.List(tempDir, regexp.MustCompile(`^app\.log\.`))
+	assert.Nil(err)
+	assert.Equal([]string{tempFile + ".2017-03-04T15-00-00.gz"}, archives)
+
+	contents, err := readCompressedFile(tempFile + ".2017-03-04T15-00-00.gz")
+	assert.Nil(err)
+	assert.Equal("staged\n", contents)
+}
+
+func readCompressedFile(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	gzr, err := gzip.NewReader(file)
+	if err != nil {
+		return "", err
+	}
+	defer gzr.Close()
+
+	contents, err := ioutil.ReadAll(gzr)
+	return string(contents), err
+}
+
+func TestFileOutputReopen(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+
+	tempFile := filepath.Join(tempDir, "app.log")
+	fo, err := NewFileOutput(tempFile, false, FileOutputUnlimitedSize, FileOutputUnlimitedArchiveFiles)
+	assert.Nil(err)
+	defer fo.Close()
+
+	_, err = fo.Write([]byte("before\n"))
+	assert.Nil(err)
+	assert.Nil(os.Rename(tempFile, tempFile+".moved"))
+	_, err = fo.Write([]byte("moved\n"))
+	assert.Nil(err)
+
+	assert.Nil(fo.Reopen())
+	_, err = fo.Write([]byte("after\n"))
+	assert.Nil(err)
+
+	moved, err := ioutil.ReadFile(tempFile + ".moved")
+	assert.Nil(err)
+	assert.Equal("before\nmoved\n", string(moved))
+	current, err := ioutil.ReadFile(tempFile)
+	assert.Nil(err)
+	assert.Equal("after\n", string(current))
+}
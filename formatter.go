@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+)
+
+const (
+	// OutputFormatText writes events as (optionally colorized) space separated text lines.
+	OutputFormatText OutputFormat = "text"
+	// OutputFormatJSON writes events as one json object per line.
+	OutputFormatJSON OutputFormat = "json"
+	// OutputFormatLogfmt writes events as space separated `key=value` pairs, one event per line.
+	OutputFormatLogfmt OutputFormat = "logfmt"
+)
+
+// OutputFormat is the name of a built in formatter.
+type OutputFormat string
+
+// ParseOutputFormat parses an output format value, returning the default format if the value is empty or unknown.
+// Values are case insensitive.
+func ParseOutputFormat(value string) OutputFormat {
+	switch OutputFormat(strings.ToLower(strings.TrimSpace(value))) {
+	case OutputFormatJSON:
+		return OutputFormatJSON
+	case OutputFormatLogfmt:
+		return OutputFormatLogfmt
+	case OutputFormatText:
+		return OutputFormatText
+	}
+	return DefaultWriterOutputFormat
+}
+
+// NewFormatter returns the built in formatter for an output format, defaulting to a `TextFormatter`.
+func NewFormatter(format OutputFormat) Formatter {
+	switch format {
+	case OutputFormatJSON:
+		return &JSONFormatter{}
+	case OutputFormatLogfmt:
+		return &LogfmtFormatter{}
+	}
+	return &TextFormatter{}
+}
+
+// Record is a fully described log event.
+type Record struct {
+	// TimeSource is when the event happened.
+	TimeSource TimeSource
+	// Event is the event flag, it is empty for plain messages.
+	Event EventFlag
+	// Color is a hint for the color of the event label; formatters are free to ignore it.
+	Color AnsiColorCode
+	// Label is the descriptive label of the writer.
+	Label string
+	// Message is the (already formatted) message.
+	Message string
+	// Err is the error for error events, if set it takes the place of the message.
+	Err error
+	// Fields are the structured fields of the event.
+	Fields Fields
+}
+
+// Formatter renders records into bytes.
+// Formatters should not write a trailing newline; the writer terminates each record.
+type Formatter interface {
+	Format(wr *Writer, buf *bytes.Buffer, record *Record) error
+}
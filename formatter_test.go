@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	assert "github.com/blendlabs/go-assert"
+)
+
+type upperFormatter struct{}
+
+func (uf upperFormatter) Format(wr *Writer, buf *bytes.Buffer, record *Record) error {
+	buf.WriteString(strings.ToUpper(string(record.Event) + " " + record.Message + " " + record.Fields.String()))
+	return nil
+}
+
+func TestNewFormatter(t *testing.T) {
+	assert := assert.New(t)
+
+	_, isText := NewFormatter(OutputFormatText).(*TextFormatter)
+	assert.True(isText)
+	_, isJSON := NewFormatter(OutputFormatJSON).(*JSONFormatter)
+	assert.True(isJSON)
+	_, isLogfmt := NewFormatter(OutputFormatLogfmt).(*LogfmtFormatter)
+	assert.True(isLogfmt)
+	_, isText = NewFormatter("not a format").(*TextFormatter)
+	assert.True(isText)
+}
+
+func TestWriterCustomFormatter(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := bytes.NewBuffer(nil)
+	writer := NewWriter(buffer)
+	writer.SetFormatter(upperFormatter{})
+	assert.Equal(OutputFormat(""), writer.OutputFormat())
+	assert.Equal("foo", writer.Colorize("foo", ColorBlue))
+
+	writer.PrintEventfWithTimeSource(SystemClock, EventInfo, ColorWhite, NewFields("foo", "bar"), "hello %s", "world")
+	assert.Equal("INFO HELLO WORLD FOO=BAR\n", buffer.String())
+}
+
+func TestWriterFprintRecordDefaults(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := bytes.NewBuffer(nil)
+	writer := NewWriter(buffer)
+	writer.SetOutputFormat(OutputFormatJSON)
+	writer.SetShowTimestamp(false)
+	writer.SetLabel("unit-test")
+
+	record := &Record{Event: EventInfo, Message: "hello"}
+	writer.PrintRecord(record)
+	assert.Equal(SystemClock, record.TimeSource)
+	assert.Equal(`{"event":"info","label":"unit-test","message":"hello"}`+"\n", buffer.String())
+}
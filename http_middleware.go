@@ -0,0 +1,231 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	exception "github.com/blendlabs/go-exception"
+)
+
+// NewMiddleware returns a new http middleware that logs requests to an agent.
+func NewMiddleware(agent *Agent) *Middleware {
+	return &Middleware{
+		agent:           agent,
+		defaultRate:     1,
+		requestIDHeader: DefaultRequestIDHeader,
+	}
+}
+
+// Middleware logs the requests an http handler serves.
+// For each request it fires `EventWebRequestStart` (with the request) and `EventWebRequest` (with the request, status code,
+// content length and elapsed time), the events the `NewRequestStartListener` and `NewRequestListener` listeners handle.
+// Request and response bodies can be captured into `EventWebRequestPostBody` and `EventWebResponse`, up to a size limit.
+// Panics in the handler are recovered and logged as `EventFatalError` with the request.
+//
+// Each request is given an id, read from the request id header (`X-Request-Id` by default) or generated, and written back to the
+// response's header. The id is carried by the request's context (see `RequestID`), added to the request's events as a `request_id`
+// field, and written by `WriteRequestStart`, `WriteRequest` and the `...WithReq` methods.
+// Handlers can log with the request's scoped agent, `FromContext(req.Context())`, which adds the id to everything it writes.
+// The middleware should be configured before it serves requests.
+type Middleware struct {
+	agent *Agent
+
+	excludedPaths map[string]bool
+	routeFunc     func(*http.Request) string
+	defaultRate   float64
+	routeRates    map[string]float64
+	randLock      sync.Mutex
+	rand          *rand.Rand
+
+	maxRequestBody  int
+	maxResponseBody int
+
+	requestIDHeader string
+}
+
+// Agent returns the agent requests are logged to.
+func (m *Middleware) Agent() *Agent {
+	return m.agent
+}
+
+// SetExcludedPaths sets paths (e.g. health checks) that aren't logged at all.
+// Paths are matched exactly against the request's url path.
+func (m *Middleware) SetExcludedPaths(paths ...string) {
+	m.excludedPaths = make(map[string]bool, len(paths))
+	for _, path := range paths {
+		m.excludedPaths[path] = true
+	}
+}
+
+// SetRouteFunc sets how the route of a request is found for sampling, e.g. to map `/users/1234` to `/users/:id`.
+// By default the route is the request's url path.
+func (m *Middleware) SetRouteFunc(routeFunc func(*http.Request) string) {
+	m.routeFunc = routeFunc
+}
+
+// SetSampleRate sets the fraction (from 0 to 1) of requests that are logged, for routes without a rate of their own.
+// It defaults to 1, logging every request.
+func (m *Middleware) SetSampleRate(rate float64) {
+	m.defaultRate = rate
+}
+
+// SetRouteSampleRate sets the fraction (from 0 to 1) of requests to a route that are logged.
+// Panics are logged whether or not their request is sampled.
+func (m *Middleware) SetRouteSampleRate(route string, rate float64) {
+	if m.routeRates == nil {
+		m.routeRates = map[string]float64{}
+	}
+	m.routeRates[route] = rate
+}
+
+// SetCaptureRequestBody sets how many bytes of request bodies are captured for `EventWebRequestPostBody`; 0 disables capture.
+// The body is captured as the handler reads it, so only what the handler reads is logged.
+func (m *Middleware) SetCaptureRequestBody(maxBytes int) {
+	m.maxRequestBody = maxBytes
+}
+
+// SetCaptureResponseBody sets how many bytes of response bodies are captured for `EventWebResponse`; 0 disables capture.
+func (m *Middleware) SetCaptureResponseBody(maxBytes int) {
+	m.maxResponseBody = maxBytes
+}
+
+// SetRequestIDHeader sets the header request ids are read from and written to; an empty header neither reads nor writes them.
+// Incoming ids that are too long or have spaces or control characters in them are replaced.
+func (m *Middleware) SetRequestIDHeader(header string) {
+	m.requestIDHeader = header
+}
+
+// RequestIDHeader returns the header request ids are read from and written to.
+func (m *Middleware) RequestIDHeader() string {
+	return m.requestIDHeader
+}
+
+// Handler returns a handler that logs the requests a handler serves.
+func (m *Middleware) Handler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		m.serveHTTP(handler, res, req)
+	})
+}
+
+// HandlerFunc returns a handler func that logs the requests a handler func serves.
+func (m *Middleware) HandlerFunc(handler http.HandlerFunc) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		m.serveHTTP(handler, res, req)
+	}
+}
+
+func (m *Middleware) serveHTTP(handler http.Handler, res http.ResponseWriter, req *http.Request) {
+	if m.excludedPaths[req.URL.Path] {
+		handler.ServeHTTP(res, req)
+		return
+	}
+
+	start := time.Now()
+	requestID := m.requestID(req)
+	if len(m.requestIDHeader) > 0 {
+		res.Header().Set(m.requestIDHeader, requestID)
+	}
+	agent := m.agent.With(FieldRequestID, requestID)
+	req = req.WithContext(WithContext(WithRequestID(req.Context(), requestID), agent))
+
+	sampled := m.sample(req)
+	rw := NewResponseWriter(res)
+
+	var requestBody *capturedReader
+	if sampled {
+		agent.OnEvent(EventWebRequestStart, req)
+		if m.maxRequestBody > 0 && req.Body != nil && m.isListenedTo(EventWebRequestPostBody) {
+			requestBody = &capturedReader{ReadCloser: req.Body, body: bytes.NewBuffer(nil), limit: m.maxRequestBody}
+			req.Body = requestBody
+		}
+		if m.maxResponseBody > 0 && m.isListenedTo(EventWebResponse) {
+			rw.captureBody(m.maxResponseBody)
+		}
+	}
+
+	defer func() {
+		if r := recover(); r == http.ErrAbortHandler {
+			// the handler is aborting the response on purpose; let the server see it once the request is logged.
+			defer panic(r)
+		} else if r != nil {
+			agent.FatalWithReq(exception.New(r), req)
+			if rw.StatusCode() == 0 {
+				rw.WriteHeader(http.StatusInternalServerError)
+			}
+		}
+		if !sampled {
+			return
+		}
+		if requestBody != nil && requestBody.body.Len() > 0 {
+			agent.OnEvent(EventWebRequestPostBody, requestBody.body.Bytes())
+		}
+		if body := rw.capturedBody(); len(body) > 0 {
+			agent.OnEvent(EventWebResponse, body)
+		}
+		agent.OnEvent(EventWebRequest, req, rw.StatusCode(), rw.ContentLength(), time.Since(start))
+	}()
+
+	handler.ServeHTTP(rw.Passthrough(), req)
+}
+
+// requestID returns the id of a request from its request id header, or a new id if it doesn't have a valid one.
+func (m *Middleware) requestID(req *http.Request) string {
+	if len(m.requestIDHeader) > 0 {
+		if requestID := req.Header.Get(m.requestIDHeader); isValidRequestID(requestID) {
+			return requestID
+		}
+	}
+	return UUIDv4()
+}
+
+// sample returns if a request should be logged, by the sample rate of its route.
+func (m *Middleware) sample(req *http.Request) bool {
+	rate := m.defaultRate
+	if len(m.routeRates) > 0 {
+		route := req.URL.Path
+		if m.routeFunc != nil {
+			route = m.routeFunc(req)
+		}
+		if routeRate, hasRate := m.routeRates[route]; hasRate {
+			rate = routeRate
+		}
+	}
+	if rate >= 1 {
+		return true
+	}
+	if rate <= 0 {
+		return false
+	}
+
+	m.randLock.Lock()
+	defer m.randLock.Unlock()
+	if m.rand == nil {
+		m.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return m.rand.Float64() < rate
+}
+
+// isListenedTo returns if an event would be fired, so bodies aren't captured for no one.
+func (m *Middleware) isListenedTo(eventFlag EventFlag) bool {
+	return m.agent.IsEnabled(eventFlag) && m.agent.HasListener(eventFlag)
+}
+
+// capturedReader is a request body that keeps the start of what is read from it.
+type capturedReader struct {
+	io.ReadCloser
+	body  *bytes.Buffer
+	limit int
+}
+
+// Read implements io.Reader.
+func (cr *capturedReader) Read(p []byte) (int, error) {
+	n, err := cr.ReadCloser.Read(p)
+	if n > 0 {
+		writeBounded(cr.body, cr.limit, p[:n])
+	}
+	return n, err
+}
@@ -0,0 +1,171 @@
+package logger
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	assert "github.com/blendlabs/go-assert"
+)
+
+// middlewareEvents collects the web events an agent fires.
+type middlewareEvents struct {
+	sync.Mutex
+	starts    []string
+	requests  []string
+	statuses  []int
+	lengths   []int
+	elapsed   []time.Duration
+	postBody  []string
+	responses []string
+	fatals    []*http.Request
+}
+
+func newMiddlewareTestAgent() (*Agent, *middlewareEvents) {
+	da := NewWithWriter(NewEventFlagSet(EventWebRequestStart, EventWebRequest, EventWebRequestPostBody, EventWebResponse, EventFatalError), NewWriter(ioutil.Discard))
+	events := &middlewareEvents{}
+	da.AddEventListener(EventWebRequestStart, NewRequestStartListener(func(wr *Writer, ts TimeSource, req *http.Request) {
+		events.Lock()
+		defer events.Unlock()
+		events.starts = append(events.starts, req.URL.Path)
+	}))
+	da.AddEventListener(EventWebRequest, NewRequestListener(func(wr *Writer, ts TimeSource, req *http.Request, statusCode, contentLengthBytes int, elapsed time.Duration) {
+		events.Lock()
+		defer events.Unlock()
+		events.requests = append(events.requests, req.URL.Path)
+		events.statuses = append(events.statuses, statusCode)
+		events.lengths = append(events.lengths, contentLengthBytes)
+		events.elapsed = append(events.elapsed, elapsed)
+	}))
+	da.AddEventListener(EventWebRequestPostBody, NewRequestBodyListener(func(wr *Writer, ts TimeSource, body []byte, fields Fields) {
+		events.Lock()
+		defer events.Unlock()
+		events.postBody = append(events.postBody, string(body))
+	}))
+	da.AddEventListener(EventWebResponse, NewResponseListener(func(wr *Writer, ts TimeSource, body []byte, fields Fields) {
+		events.Lock()
+		defer events.Unlock()
+		events.responses = append(events.responses, string(body))
+	}))
+	da.AddEventListener(EventFatalError, NewErrorWithRequestListener(func(wr *Writer, ts TimeSource, err error, req *http.Request) {
+		events.Lock()
+		defer events.Unlock()
+		events.fatals = append(events.fatals, req)
+	}))
+	return da, events
+}
+
+func TestMiddleware(t *testing.T) {
+	assert := assert.New(t)
+
+	da, events := newMiddlewareTestAgent()
+	defer da.Close()
+
+	var scoped *ScopedAgent
+	var isFlusher, isHijacker bool
+	handler := NewMiddleware(da).HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		scoped = FromContext(req.Context())
+		_, isFlusher = res.(http.Flusher)
+		_, isHijacker = res.(http.Hijacker)
+		res.WriteHeader(http.StatusCreated)
+		res.Write([]byte("created"))
+	})
+
+	res := httptest.NewRecorder()
+	handler(res, httptest.NewRequest("POST", "/users", strings.NewReader("name=bailey")))
+	assert.Nil(da.Flush(context.Background()))
+
+	assert.Equal(http.StatusCreated, res.Code)
+	assert.Equal(da, scoped.Agent())
+	assert.True(isFlusher, "the handler should see the recorder's optional interfaces")
+	assert.False(isHijacker, "the handler shouldn't see optional interfaces the recorder doesn't implement")
+	assert.Equal([]string{"/users"}, events.starts)
+	assert.Equal([]string{"/users"}, events.requests)
+	assert.Equal([]int{http.StatusCreated}, events.statuses)
+	assert.Equal([]int{len("created")}, events.lengths)
+	assert.Empty(events.postBody, "bodies shouldn't be captured by default")
+	assert.Empty(events.responses, "bodies shouldn't be captured by default")
+}
+
+func TestMiddlewareCapturesBodies(t *testing.T) {
+	assert := assert.New(t)
+
+	da, events := newMiddlewareTestAgent()
+	defer da.Close()
+
+	middleware := NewMiddleware(da)
+	middleware.SetCaptureRequestBody(4)
+	middleware.SetCaptureResponseBody(8)
+	var received string
+	handler := middleware.Handler(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		received = string(body)
+		res.Write([]byte(`{"status":"ok!"}`))
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/post", strings.NewReader("name=bailey")))
+	assert.Nil(da.Flush(context.Background()))
+
+	assert.Equal("name=bailey", received, "the handler should read the whole body")
+	assert.Equal([]string{"name"}, events.postBody)
+	assert.Equal([]string{`{"status`}, events.responses)
+	assert.Equal([]int{len(`{"status":"ok!"}`)}, events.lengths)
+}
+
+func TestMiddlewareRecoversPanics(t *testing.T) {
+	assert := assert.New(t)
+
+	da, events := newMiddlewareTestAgent()
+	defer da.Close()
+
+	middleware := NewMiddleware(da)
+	middleware.SetSampleRate(0)
+	handler := middleware.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		panic("boom")
+	})
+
+	res := httptest.NewRecorder()
+	handler(res, httptest.NewRequest("GET", "/fatal", nil))
+	assert.Nil(da.Flush(context.Background()))
+
+	assert.Equal(http.StatusInternalServerError, res.Code)
+	assert.Len(events.fatals, 1)
+	assert.Equal("/fatal", events.fatals[0].URL.Path)
+	assert.Empty(events.requests, "unsampled requests shouldn't be logged")
+}
+
+func TestMiddlewareExcludesAndSamples(t *testing.T) {
+	assert := assert.New(t)
+
+	da, events := newMiddlewareTestAgent()
+	defer da.Close()
+
+	middleware := NewMiddleware(da)
+	middleware.SetExcludedPaths("/healthz")
+	middleware.SetRouteFunc(func(req *http.Request) string {
+		if strings.HasPrefix(req.URL.Path, "/users/") {
+			return "/users/:id"
+		}
+		return req.URL.Path
+	})
+	middleware.SetRouteSampleRate("/users/:id", 0)
+
+	var served int
+	handler := middleware.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		served++
+		res.WriteHeader(http.StatusOK)
+	})
+	for _, path := range []string{"/healthz", "/users/1", "/users/2", "/"} {
+		handler(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	assert.Nil(da.Flush(context.Background()))
+
+	assert.Equal(4, served)
+	assert.Equal([]string{"/"}, events.starts)
+	assert.Equal([]string{"/"}, events.requests)
+}
@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// AbandonedEventsError is returned when a flush or drain gives up before the queued events finish.
+type AbandonedEventsError struct {
+	// Abandoned is the number of queued events that hadn't finished.
+	Abandoned int64
+	// Err is the context error that ended the wait.
+	Err error
+}
+
+// Error implements error.
+func (aee *AbandonedEventsError) Error() string {
+	return fmt.Sprintf("%v; abandoned %d queued events", aee.Err, aee.Abandoned)
+}
+
+// Unwrap returns the context error.
+func (aee *AbandonedEventsError) Unwrap() error {
+	return aee.Err
+}
+
+// inFlight counts actions that have been queued but haven't finished running.
+// The zero value is ready to use.
+type inFlight struct {
+	lock  sync.Mutex
+	count int64
+	idle  chan struct{}
+}
+
+// Add counts a queued action.
+func (f *inFlight) Add() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.count == 0 {
+		f.idle = make(chan struct{})
+	}
+	f.count++
+}
+
+// Done marks a queued action as finished (or discarded).
+func (f *inFlight) Done() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.count == 0 {
+		return
+	}
+	f.count--
+	if f.count == 0 {
+		close(f.idle)
+	}
+}
+
+// Count returns the number of unfinished actions.
+func (f *inFlight) Count() int64 {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.count
+}
+
+// Wait waits until there are no unfinished actions, or the context is done.
+func (f *inFlight) Wait(ctx context.Context) error {
+	f.lock.Lock()
+	if f.count == 0 {
+		f.lock.Unlock()
+		return nil
+	}
+	idle := f.idle
+	f.lock.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return &AbandonedEventsError{Abandoned: f.Count(), Err: ctx.Err()}
+	}
+}
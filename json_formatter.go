@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	// JSONFieldTimestamp is the json key for the event timestamp.
+	JSONFieldTimestamp = "timestamp"
+	// JSONFieldEvent is the json key for the event flag.
+	JSONFieldEvent = "event"
+	// JSONFieldLabel is the json key for the writer label.
+	JSONFieldLabel = "label"
+	// JSONFieldMessage is the json key for the event message.
+	JSONFieldMessage = "message"
+	// JSONFieldStack is the json key for the extended (`%+v`) error output, if it differs from the error message.
+	JSONFieldStack = "stack"
+
+	// jsonFieldPrefix is prepended to structured field keys that collide with the keys above.
+	jsonFieldPrefix = "fields."
+)
+
+// JSONFormatter renders records as a single json object.
+// Structured fields are written as top level keys after the standard keys in the order they were added.
+type JSONFormatter struct{}
+
+// Format implements Formatter.
+func (jf *JSONFormatter) Format(wr *Writer, buf *bytes.Buffer, record *Record) error {
+	buf.WriteRune('{')
+	var keys int
+	writeKey := func(key string) {
+		if keys > 0 {
+			buf.WriteRune(',')
+		}
+		writeJSONValue(buf, key)
+		buf.WriteRune(':')
+		keys++
+	}
+
+	if wr.showTimestamp {
+		writeKey(JSONFieldTimestamp)
+		writeJSONValue(buf, wr.getTimeFormatted(record.TimeSource))
+	}
+	if len(record.Event) > 0 {
+		writeKey(JSONFieldEvent)
+		writeJSONValue(buf, string(record.Event))
+	}
+	if len(record.Label) > 0 {
+		writeKey(JSONFieldLabel)
+		writeJSONValue(buf, record.Label)
+	}
+	if record.Err != nil {
+		writeKey(JSONFieldMessage)
+		writeJSONValue(buf, record.Err.Error())
+		if stack := fmt.Sprintf("%+v", record.Err); stack != record.Err.Error() {
+			writeKey(JSONFieldStack)
+			writeJSONValue(buf, stack)
+		}
+	} else if len(record.Message) > 0 {
+		writeKey(JSONFieldMessage)
+		writeJSONValue(buf, record.Message)
+	}
+	for _, field := range record.Fields {
+		if isReservedJSONField(field.Key) {
+			writeKey(jsonFieldPrefix + field.Key)
+		} else {
+			writeKey(field.Key)
+		}
+		writeJSONValue(buf, field.Value)
+	}
+	buf.WriteRune('}')
+	return nil
+}
+
+func isReservedJSONField(key string) bool {
+	switch key {
+	case JSONFieldTimestamp, JSONFieldEvent, JSONFieldLabel, JSONFieldMessage, JSONFieldStack:
+		return true
+	}
+	return false
+}
+
+// writeJSONValue writes a value as json, falling back to its `%v` string form if it can't be marshalled.
+func writeJSONValue(buf *bytes.Buffer, value interface{}) {
+	switch typed := value.(type) {
+	case error:
+		value = typed.Error()
+	case time.Duration:
+		value = typed.String()
+	}
+	contents, err := json.Marshal(value)
+	if err != nil {
+		contents, _ = json.Marshal(fmt.Sprintf("%v", value))
+	}
+	buf.Write(contents)
+}
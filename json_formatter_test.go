@@ -0,0 +1,101 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	assert "github.com/blendlabs/go-assert"
+	exception "github.com/blendlabs/go-exception"
+)
+
+func TestParseOutputFormat(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(OutputFormatJSON, ParseOutputFormat("json"))
+	assert.Equal(OutputFormatJSON, ParseOutputFormat(" JSON "))
+	assert.Equal(OutputFormatLogfmt, ParseOutputFormat("logfmt"))
+	assert.Equal(OutputFormatText, ParseOutputFormat("text"))
+	assert.Equal(OutputFormatText, ParseOutputFormat(""))
+	assert.Equal(OutputFormatText, ParseOutputFormat("not a format"))
+}
+
+func TestNewWriterFromEnvironmentOutputFormat(t *testing.T) {
+	assert := assert.New(t)
+
+	oldLogFormat := os.Getenv(EnvironmentVariableLogFormat)
+	defer func() {
+		os.Setenv(EnvironmentVariableLogFormat, oldLogFormat)
+	}()
+	os.Setenv(EnvironmentVariableLogFormat, "json")
+
+	writer := NewWriterFromEnvironment()
+	assert.Equal(OutputFormatJSON, writer.OutputFormat())
+}
+
+func TestWriterJSONPrintf(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := bytes.NewBuffer(nil)
+	writer := NewWriter(buffer)
+	writer.SetOutputFormat(OutputFormatJSON)
+	writer.SetShowTimestamp(false)
+	writer.SetLabel("unit-test")
+
+	writer.Printf("test %q", "string")
+	assert.Equal(`{"label":"unit-test","message":"test \"string\""}`+"\n", buffer.String())
+}
+
+func TestWriterJSONPrintEventf(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := bytes.NewBuffer(nil)
+	writer := NewWriter(buffer)
+	writer.SetOutputFormat(OutputFormatJSON)
+
+	ts := TimeInstance(time.Date(2016, 01, 02, 03, 04, 05, 06, time.UTC))
+	writer.PrintEventfWithTimeSource(ts, EventInfo, ColorWhite, NewFields("user_id", 123, "message", "collides"), "test %s", "string")
+
+	var values map[string]interface{}
+	assert.Nil(json.Unmarshal(buffer.Bytes(), &values))
+	assert.Equal("2016-01-02T03:04:05Z", values[JSONFieldTimestamp])
+	assert.Equal("info", values[JSONFieldEvent])
+	assert.Equal("test string", values[JSONFieldMessage])
+	assert.Equal(123, values["user_id"])
+	assert.Equal("collides", values["fields.message"])
+}
+
+func TestWriterJSONWriteError(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := bytes.NewBuffer(nil)
+	writer := NewWriter(buffer)
+	writer.SetOutputFormat(OutputFormatJSON)
+	writer.SetShowTimestamp(false)
+
+	writer.WriteErrorWithTimeSource(SystemClock, EventError, ColorRed, exception.New("this is only a test"), NewFields("tenant", "acme"))
+
+	var values map[string]interface{}
+	assert.Nil(json.Unmarshal(buffer.Bytes(), &values))
+	assert.Equal("error", values[JSONFieldEvent])
+	assert.Equal("this is only a test", values[JSONFieldMessage])
+	assert.NotEmpty(values[JSONFieldStack])
+	assert.Equal("acme", values["tenant"])
+}
+
+func TestWriteRequestJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := bytes.NewBuffer(nil)
+	writer := NewWriter(buffer)
+	writer.SetOutputFormat(OutputFormatJSON)
+	writer.SetShowTimestamp(false)
+
+	req := &http.Request{Method: "GET", URL: &url.URL{Path: "/foo"}, RemoteAddr: "1.2.3.4:5678"}
+	WriteRequest(writer, SystemClock, req, http.StatusOK, 512, 1300*time.Microsecond)
+	assert.Equal(`{"event":"web.request","ip":"1.2.3.4","method":"GET","path":"/foo","status":200,"elapsed_ms":1.3,"bytes":512}`+"\n", buffer.String())
+}
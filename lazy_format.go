@@ -0,0 +1,133 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// newLazyError returns an error that is formatted with `fmt.Errorf` the first time it is read (or unwrapped).
+func newLazyError(format string, args ...interface{}) error {
+	return &lazyError{format: format, args: args}
+}
+
+// lazyError is an error formatted on demand, so events that are disabled (or not yet written) don't pay for formatting.
+// It unwraps to the errors wrapped with `%w`.
+type lazyError struct {
+	format    string
+	args      []interface{}
+	once      sync.Once
+	formatted error
+}
+
+// Error implements error.
+func (le *lazyError) Error() string {
+	return le.err().Error()
+}
+
+// Unwrap returns the error wrapped with `%w`, if there is only one.
+func (le *lazyError) Unwrap() error {
+	return errors.Unwrap(le.err())
+}
+
+// Is reports if any error wrapped with `%w` matches a target, for `errors.Is`.
+func (le *lazyError) Is(target error) bool {
+	return errors.Is(le.err(), target)
+}
+
+// As finds the first error wrapped with `%w` that matches a target, for `errors.As`.
+func (le *lazyError) As(target interface{}) bool {
+	return errors.As(le.err(), target)
+}
+
+// err formats the error, once.
+func (le *lazyError) err() error {
+	le.once.Do(func() {
+		le.formatted = fmt.Errorf(le.format, le.args...)
+		le.args = nil
+	})
+	return le.formatted
+}
+
+// SetSnapshotArgs sets if format args are snapshotted when an event is logged, rather than read when it is written.
+// Snapshots call `String()` on maps, slices and pointers that are `fmt.Stringer`s, and shallow copy the rest (a pointer's value is copied),
+// so an arg mutated after the call still prints as it was when it was logged. It only applies to enabled events.
+func (da *Agent) SetSnapshotArgs(snapshotArgs bool) {
+	var value int32
+	if snapshotArgs {
+		value = 1
+	}
+	atomic.StoreInt32(&da.snapshotArgs, value)
+}
+
+// SnapshotArgs returns if format args are snapshotted when an event is logged.
+func (da *Agent) SnapshotArgs() bool {
+	return atomic.LoadInt32(&da.snapshotArgs) == 1
+}
+
+// captureArgs returns the args to queue for an enabled event, snapshotting them if the agent is set to.
+func (da *Agent) captureArgs(args []interface{}) []interface{} {
+	if len(args) == 0 || !da.SnapshotArgs() {
+		return args
+	}
+	return snapshotArgs(args)
+}
+
+// snapshotArgs returns a copy of a set of args that later changes to the originals won't show up in.
+func snapshotArgs(args []interface{}) []interface{} {
+	snapshot := make([]interface{}, len(args))
+	for x, arg := range args {
+		snapshot[x] = snapshotArg(arg)
+	}
+	return snapshot
+}
+
+func snapshotArg(arg interface{}) (snapshot interface{}) {
+	if arg == nil {
+		return nil
+	}
+	value := reflect.ValueOf(arg)
+	switch value.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Ptr:
+		if value.IsNil() {
+			return arg
+		}
+	default:
+		// other values were copied when they were passed.
+		return arg
+	}
+
+	switch typed := arg.(type) {
+	case error:
+		// errors keep their type, so verbs like `%+v` still print their detail.
+		return typed
+	case fmt.Stringer:
+		// a panicking `String()` is left for fmt to report when the message is written.
+		defer func() {
+			if r := recover(); r != nil {
+				snapshot = arg
+			}
+		}()
+		return typed.String()
+	}
+
+	switch value.Kind() {
+	case reflect.Map:
+		copied := reflect.MakeMap(value.Type())
+		for _, key := range value.MapKeys() {
+			copied.SetMapIndex(key, value.MapIndex(key))
+		}
+		return copied.Interface()
+	case reflect.Slice:
+		copied := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+		reflect.Copy(copied, value)
+		return copied.Interface()
+	case reflect.Ptr:
+		copied := reflect.New(value.Elem().Type())
+		copied.Elem().Set(value.Elem())
+		return copied.Interface()
+	}
+	return arg
+}
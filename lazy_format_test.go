@@ -0,0 +1,115 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	assert "github.com/blendlabs/go-assert"
+)
+
+// countingStringer counts how many times it is formatted.
+type countingStringer struct {
+	calls int
+}
+
+func (cs *countingStringer) String() string {
+	cs.calls++
+	return "counted"
+}
+
+func TestLazyError(t *testing.T) {
+	assert := assert.New(t)
+
+	counter := &countingStringer{}
+	err := newLazyError("this is %s", counter)
+	assert.Zero(counter.calls)
+	assert.Equal("this is counted", err.Error())
+	assert.Equal("this is counted", err.Error())
+	assert.Equal(1, counter.calls)
+}
+
+func TestLazyErrorUnwrap(t *testing.T) {
+	assert := assert.New(t)
+
+	base := errors.New("boom")
+	err := newLazyError("wrap: %w", base)
+	assert.True(errors.Is(err, base))
+	assert.Equal(base, errors.Unwrap(err))
+	assert.Equal("wrap: boom", err.Error())
+
+	var target *ListenerError
+	listenerErr := &ListenerError{Event: EventInfo}
+	assert.True(errors.As(newLazyError("listener: %w", listenerErr), &target))
+	assert.Equal(listenerErr, target)
+}
+
+func TestAgentWarningfDisabledDoesNotFormat(t *testing.T) {
+	assert := assert.New(t)
+
+	da := NewWithWriter(NewEventFlagSetNone(), NewWriter(bytes.NewBuffer(nil)))
+	defer da.Close()
+
+	counter := &countingStringer{}
+	err := da.Warningf("this is %s", counter)
+	assert.Nil(da.Flush(context.Background()))
+	assert.Zero(counter.calls)
+	assert.Equal("this is counted", err.Error())
+}
+
+func TestAgentErrorfSnapshotsArgs(t *testing.T) {
+	assert := assert.New(t)
+
+	da := NewWithWriter(NewEventFlagSet(EventWarning), NewWriter(bytes.NewBuffer(nil)))
+	defer da.Close()
+	da.SetSnapshotArgs(true)
+
+	values := map[string]int{"a": 1}
+	err := da.Warningf("values=%v", values)
+	values["a"] = 2
+	assert.Nil(da.Flush(context.Background()))
+	assert.Equal("values=map[a:1]", err.Error())
+}
+
+func TestSnapshotArgs(t *testing.T) {
+	assert := assert.New(t)
+
+	values := map[string]int{"a": 1}
+	list := []int{1, 2}
+	type point struct{ X, Y int }
+	pos := &point{X: 1, Y: 2}
+	counter := &countingStringer{}
+
+	snapshot := snapshotArgs([]interface{}{values, list, pos, counter, 3, "text", nil})
+	values["a"] = 2
+	list[0] = 3
+	pos.X = 3
+
+	assert.Equal(map[string]int{"a": 1}, snapshot[0])
+	assert.Equal([]int{1, 2}, snapshot[1])
+	assert.Equal(&point{X: 1, Y: 2}, snapshot[2])
+	assert.Equal("counted", snapshot[3])
+	assert.Equal(3, snapshot[4])
+	assert.Equal("text", snapshot[5])
+	assert.Nil(snapshot[6])
+}
+
+func TestAgentSnapshotArgs(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := bytes.NewBuffer(nil)
+	writer := NewWriter(buffer)
+	writer.SetShowTimestamp(false)
+	writer.SetUseAnsiColors(false)
+	da := NewWithWriter(NewEventFlagSet(EventInfo), writer)
+	defer da.Close()
+	da.SetSnapshotArgs(true)
+	assert.True(da.SnapshotArgs())
+
+	values := map[string]int{"a": 1}
+	da.Infof("values %v", values)
+	values["a"] = 2
+	assert.Nil(da.Flush(context.Background()))
+	assert.Contains(buffer.String(), "[info] values map[a:1]")
+}
@@ -0,0 +1,106 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"runtime/debug"
+	"time"
+)
+
+const (
+	// EventListenerError is an event that fires when an event listener panics or runs past the listener timeout.
+	// Listener errors are reported unless the agent's events disable it explicitly (e.g. with `-logger.listener_error`, or `none`).
+	EventListenerError EventFlag = "logger.listener_error"
+)
+
+// ListenerError is the error written for `EventListenerError`.
+type ListenerError struct {
+	// Event is the event the listener was handling.
+	Event EventFlag
+	// Listener is the name of the listener, if it was added with one.
+	Listener string
+	// Panic is the value the listener panicked with, if it panicked.
+	Panic interface{}
+	// Stack is the stack of the panicking listener, if it panicked.
+	Stack []byte
+	// Timeout is the timeout the listener ran past, if it timed out.
+	Timeout time.Duration
+}
+
+// Error implements error.
+func (le *ListenerError) Error() string {
+	listener := "listener"
+	if len(le.Listener) > 0 {
+		listener = fmt.Sprintf("listener `%s`", le.Listener)
+	}
+	if le.Timeout > 0 {
+		return fmt.Sprintf("%s for `%s` is still running after %v", listener, le.Event, le.Timeout)
+	}
+	return fmt.Sprintf("%s for `%s` panicked: %v", listener, le.Event, le.Panic)
+}
+
+// Format implements fmt.Formatter, adding the stack for `%+v`.
+func (le *ListenerError) Format(s fmt.State, verb rune) {
+	io.WriteString(s, le.Error())
+	if verb == 'v' && s.Flag('+') && len(le.Stack) > 0 {
+		io.WriteString(s, "\n")
+		s.Write(le.Stack)
+	}
+}
+
+// invokeListener calls a listener, recovering if it panics.
+// If the agent has a listener timeout, the listener is called on its own goroutine and abandoned once it runs past the timeout.
+func (da *Agent) invokeListener(listener registeredListener, ts TimeSource, eventFlag EventFlag, state ...interface{}) {
+	timeout := da.ListenerTimeout()
+	if timeout <= 0 {
+		if err := da.callListener(listener, ts, eventFlag, state...); err != nil {
+			da.reportListenerError(err)
+		}
+		return
+	}
+
+	done := make(chan *ListenerError, 1)
+	go func() {
+		done <- da.callListener(listener, ts, eventFlag, state...)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		if err != nil {
+			da.reportListenerError(err)
+		}
+	case <-timer.C:
+		da.reportListenerError(&ListenerError{Event: eventFlag, Listener: listener.Name, Timeout: timeout})
+		go func() {
+			if err := <-done; err != nil {
+				da.reportListenerError(err)
+			}
+		}()
+	}
+}
+
+// callListener calls a listener, returning an error if it panics.
+func (da *Agent) callListener(listener registeredListener, ts TimeSource, eventFlag EventFlag, state ...interface{}) (err *ListenerError) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &ListenerError{Event: eventFlag, Listener: listener.Name, Panic: r, Stack: debug.Stack()}
+		}
+	}()
+	listener.Listener(da.writer, ts, eventFlag, state...)
+	return nil
+}
+
+// reportListenerError writes a listener error synchronously, as it is raised on a queue worker.
+// Errors from listeners for `EventListenerError` itself aren't reported, so a broken listener can't loop.
+func (da *Agent) reportListenerError(err *ListenerError) {
+	if err.Event == EventListenerError || !da.reportsEvent(EventListenerError) {
+		return
+	}
+	fields := NewFields("event", string(err.Event))
+	da.writeErr(TimeNow(), EventListenerError, ColorRed, fields, err)
+	if da.HasListener(EventListenerError) {
+		da.triggerListeners(appendFields([]interface{}{TimeNow(), EventListenerError, err}, fields)...)
+	}
+}
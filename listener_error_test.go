@@ -0,0 +1,107 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	assert "github.com/blendlabs/go-assert"
+)
+
+func TestListenerErrorFormat(t *testing.T) {
+	assert := assert.New(t)
+
+	err := &ListenerError{Event: EventInfo, Panic: "boom", Stack: []byte("goroutine 1 [running]:")}
+	assert.Equal("listener for `info` panicked: boom", err.Error())
+	assert.Equal("listener for `info` panicked: boom\ngoroutine 1 [running]:", fmt.Sprintf("%+v", err))
+
+	err = &ListenerError{Event: EventInfo, Listener: "webhook", Timeout: time.Second}
+	assert.Equal("listener `webhook` for `info` is still running after 1s", err.Error())
+}
+
+func TestAgentListenerPanic(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := bytes.NewBuffer(nil)
+	writer := NewWriter(buffer)
+	writer.SetShowTimestamp(false)
+	writer.SetUseAnsiColors(false)
+	da := NewWithWriter(NewEventFlagSet(EventInfo), writer)
+	defer da.Close()
+
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	da.AddEventListener(EventInfo, func(wr *Writer, ts TimeSource, eventFlag EventFlag, state ...interface{}) {
+		defer wg.Done()
+		panic("boom")
+	})
+	da.AddEventListener(EventInfo, func(wr *Writer, ts TimeSource, eventFlag EventFlag, state ...interface{}) {
+		defer wg.Done()
+	})
+
+	var reported *ListenerError
+	da.AddEventListener(EventListenerError, func(wr *Writer, ts TimeSource, eventFlag EventFlag, state ...interface{}) {
+		reported = state[0].(*ListenerError)
+	})
+	assert.False(da.IsEnabled(EventListenerError))
+
+	da.OnEvent(EventInfo, "Hello")
+	wg.Wait()
+	assert.Nil(da.Flush(context.Background()))
+
+	assert.NotNil(reported)
+	assert.Equal(EventInfo, reported.Event)
+	assert.Equal("boom", reported.Panic)
+	assert.NotEmpty(reported.Stack)
+	assert.Contains(buffer.String(), "[logger.listener_error] listener for `info` panicked: boom")
+}
+
+func TestAgentListenerErrorDisabled(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := bytes.NewBuffer(nil)
+	writer := NewWriter(buffer)
+	writer.SetShowTimestamp(false)
+	writer.SetUseAnsiColors(false)
+	events := NewEventFlagSet(EventInfo)
+	events.Disable(EventListenerError)
+	da := NewWithWriter(events, writer)
+	defer da.Close()
+
+	da.AddEventListener(EventInfo, func(wr *Writer, ts TimeSource, eventFlag EventFlag, state ...interface{}) {
+		panic("boom")
+	})
+	da.OnEvent(EventInfo, "Hello")
+	assert.Nil(da.Flush(context.Background()))
+	assert.NotContains(buffer.String(), "logger.listener_error")
+}
+
+func TestAgentListenerTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := bytes.NewBuffer(nil)
+	writer := NewWriter(buffer)
+	writer.SetShowTimestamp(false)
+	writer.SetUseAnsiColors(false)
+	da := NewWithWriter(NewEventFlagSet(EventInfo), writer)
+	defer da.Close()
+	da.SetListenerTimeout(time.Millisecond)
+
+	release := make(chan struct{})
+	defer close(release)
+	ran := make(chan struct{})
+	da.AddEventListener(EventInfo, func(wr *Writer, ts TimeSource, eventFlag EventFlag, state ...interface{}) {
+		<-release
+	})
+	da.AddEventListener(EventInfo, func(wr *Writer, ts TimeSource, eventFlag EventFlag, state ...interface{}) {
+		close(ran)
+	})
+
+	da.OnEvent(EventInfo, "Hello")
+	<-ran
+	assert.Nil(da.Flush(context.Background()))
+	assert.Contains(buffer.String(), "[logger.listener_error] listener for `info` is still running after 1ms")
+}
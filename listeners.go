@@ -8,6 +8,23 @@ import (
 // EventListener is a listener for a specific event as given by its flag.
 type EventListener func(writer *Writer, ts TimeSource, eventFlag EventFlag, state ...interface{})
 
+// ListenerID identifies a registered listener, so it can be removed with `RemoveListener(...)`.
+type ListenerID uint64
+
+// ListenerInfo describes a registered listener.
+type ListenerInfo struct {
+	ID   ListenerID
+	Name string
+	// Event is the event the listener is registered for, or `EventAll` for debug listeners.
+	Event EventFlag
+}
+
+// registeredListener is a listener with its registration.
+type registeredListener struct {
+	ListenerInfo
+	Listener EventListener
+}
+
 // ErrorListener is a handler for error events.
 type ErrorListener func(writer *Writer, ts TimeSource, err error)
 
@@ -91,7 +108,8 @@ func NewRequestListener(listener RequestListener) EventListener {
 }
 
 // RequestBodyListener is a listener for request bodies.
-type RequestBodyListener func(writer *Writer, ts TimeSource, body []byte)
+// It is given the event's fields, which carry the request id the middleware adds, to pass on to `WriteRequestBody`.
+type RequestBodyListener func(writer *Writer, ts TimeSource, body []byte, fields Fields)
 
 // NewRequestBodyListener returns a new handler for request body events.
 func NewRequestBodyListener(listener RequestBodyListener) EventListener {
@@ -103,12 +121,13 @@ func NewRequestBodyListener(listener RequestBodyListener) EventListener {
 		if err != nil {
 			return
 		}
-		listener(writer, ts, body)
+		listener(writer, ts, body, FieldsFromState(state...))
 	}
 }
 
 // ResponseListener is a handler for response body events.
-type ResponseListener func(writer *Writer, ts TimeSource, body []byte)
+// It is given the event's fields, which carry the request id the middleware adds, to pass on to `WriteResponseBody`.
+type ResponseListener func(writer *Writer, ts TimeSource, body []byte, fields Fields)
 
 // NewResponseListener creates a new listener for response body events.
 func NewResponseListener(listener ResponseListener) EventListener {
@@ -120,6 +139,22 @@ func NewResponseListener(listener ResponseListener) EventListener {
 		if err != nil {
 			return
 		}
-		listener(writer, ts, res)
+		listener(writer, ts, res, FieldsFromState(state...))
+	}
+}
+
+// FieldsListener is a listener for events written with structured fields.
+type FieldsListener func(writer *Writer, ts TimeSource, eventFlag EventFlag, fields Fields, state ...interface{})
+
+// NewFieldsListener returns a new handler that separates the structured fields (if any) from the rest of the event state.
+func NewFieldsListener(listener FieldsListener) EventListener {
+	return func(writer *Writer, ts TimeSource, eventFlag EventFlag, state ...interface{}) {
+		if len(state) > 0 {
+			if fields, isFields := state[len(state)-1].(eventFields); isFields {
+				listener(writer, ts, eventFlag, Fields(fields), state[:len(state)-1]...)
+				return
+			}
+		}
+		listener(writer, ts, eventFlag, nil, state...)
 	}
 }
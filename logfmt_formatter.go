@@ -0,0 +1,114 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// LogfmtFormatter renders records as space separated `key=value` pairs.
+// It uses the same keys as the json format; structured fields are written after the standard keys in the order they were added.
+type LogfmtFormatter struct{}
+
+// Format implements Formatter.
+func (lf *LogfmtFormatter) Format(wr *Writer, buf *bytes.Buffer, record *Record) error {
+	var keys int
+	writeKey := func(key string) {
+		if keys > 0 {
+			buf.WriteRune(RuneSpace)
+		}
+		writeLogfmtKey(buf, key)
+		buf.WriteRune('=')
+		keys++
+	}
+
+	if wr.showTimestamp {
+		writeKey(JSONFieldTimestamp)
+		writeLogfmtValue(buf, wr.getTimeFormatted(record.TimeSource))
+	}
+	if len(record.Event) > 0 {
+		writeKey(JSONFieldEvent)
+		writeLogfmtValue(buf, string(record.Event))
+	}
+	if len(record.Label) > 0 {
+		writeKey(JSONFieldLabel)
+		writeLogfmtValue(buf, record.Label)
+	}
+	if record.Err != nil {
+		writeKey(JSONFieldMessage)
+		writeLogfmtValue(buf, record.Err.Error())
+		if stack := fmt.Sprintf("%+v", record.Err); stack != record.Err.Error() {
+			writeKey(JSONFieldStack)
+			writeLogfmtValue(buf, stack)
+		}
+	} else if len(record.Message) > 0 {
+		writeKey(JSONFieldMessage)
+		writeLogfmtValue(buf, record.Message)
+	}
+	for _, field := range record.Fields {
+		if isReservedJSONField(field.Key) {
+			writeKey(jsonFieldPrefix + field.Key)
+		} else {
+			writeKey(field.Key)
+		}
+		writeLogfmtValue(buf, field.Value)
+	}
+	return nil
+}
+
+// writeLogfmtKey writes a key, replacing characters that would break parsing with underscores.
+func writeLogfmtKey(buf *bytes.Buffer, key string) {
+	if len(key) == 0 {
+		buf.WriteRune('_')
+		return
+	}
+	for _, r := range key {
+		if r <= RuneSpace || r == '=' || r == '"' || r == utf8.RuneError || !unicode.IsPrint(r) {
+			buf.WriteRune('_')
+		} else {
+			buf.WriteRune(r)
+		}
+	}
+}
+
+// writeLogfmtValue writes a value, quoting and escaping it if it contains spaces, quotes, equals signs or control characters.
+func writeLogfmtValue(buf *bytes.Buffer, value interface{}) {
+	var formatted string
+	switch typed := value.(type) {
+	case nil:
+		return
+	case string:
+		formatted = typed
+	case []byte:
+		formatted = string(typed)
+	case error:
+		formatted = typed.Error()
+	case time.Duration:
+		formatted = typed.String()
+	case float64:
+		formatted = strconv.FormatFloat(typed, 'f', -1, 64)
+	case float32:
+		formatted = strconv.FormatFloat(float64(typed), 'f', -1, 32)
+	default:
+		formatted = fmt.Sprintf("%v", value)
+	}
+
+	if logfmtNeedsQuoting(formatted) {
+		buf.WriteString(strconv.Quote(formatted))
+		return
+	}
+	buf.WriteString(formatted)
+}
+
+func logfmtNeedsQuoting(value string) bool {
+	if len(value) == 0 {
+		return true
+	}
+	return strings.IndexFunc(value, func(r rune) bool {
+		return r <= RuneSpace || r == '=' || r == '"' || r == '\\' || r == utf8.RuneError || !unicode.IsPrint(r)
+	}) >= 0
+}
@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	assert "github.com/blendlabs/go-assert"
+	exception "github.com/blendlabs/go-exception"
+)
+
+func TestWriteRequestLogfmt(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := bytes.NewBuffer(nil)
+	writer := NewWriter(buffer)
+	writer.SetOutputFormat(OutputFormatLogfmt)
+	writer.SetShowTimestamp(false)
+
+	req := &http.Request{Method: "GET", URL: &url.URL{Path: "/"}, RemoteAddr: "1.2.3.4:5678"}
+	WriteRequest(writer, SystemClock, req, http.StatusOK, 512, 1300*time.Microsecond)
+	assert.Equal("event=web.request ip=1.2.3.4 method=GET path=/ status=200 elapsed_ms=1.3 bytes=512\n", buffer.String())
+}
+
+func TestWriterLogfmtQuoting(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := bytes.NewBuffer(nil)
+	writer := NewWriter(buffer)
+	writer.SetOutputFormat(OutputFormatLogfmt)
+	writer.SetShowTimestamp(false)
+
+	writer.PrintEventfWithTimeSource(SystemClock, EventInfo, ColorWhite, NewFields(
+		"spaces", "foo bar",
+		"quotes", `say "hi"`,
+		"newline", "line1\nline2",
+		"equals", "a=b",
+		"empty", "",
+		"bad key", "value",
+		"plain", "value",
+	), "hello")
+	assert.Equal(`event=info message=hello spaces="foo bar" quotes="say \"hi\"" newline="line1\nline2" equals="a=b" empty="" bad_key=value plain=value`+"\n", buffer.String())
+}
+
+func TestWriterLogfmtTimestampAndLabel(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := bytes.NewBuffer(nil)
+	writer := NewWriter(buffer)
+	writer.SetOutputFormat(OutputFormatLogfmt)
+	writer.SetLabel("unit test")
+
+	ts := TimeInstance(time.Date(2016, 01, 02, 03, 04, 05, 06, time.UTC))
+	writer.PrintEventfWithTimeSource(ts, EventInfo, ColorWhite, nil, "hello world")
+	assert.Equal(`timestamp=2016-01-02T03:04:05Z event=info label="unit test" message="hello world"`+"\n", buffer.String())
+}
+
+func TestWriterLogfmtError(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := bytes.NewBuffer(nil)
+	writer := NewWriter(buffer)
+	writer.SetOutputFormat(OutputFormatLogfmt)
+	writer.SetShowTimestamp(false)
+
+	writer.WriteErrorWithTimeSource(SystemClock, EventError, ColorRed, exception.New("only a test"), nil)
+	assert.Contains(buffer.String(), `event=error message="only a test" stack=`)
+	assert.Equal(1, bytes.Count(buffer.Bytes(), []byte("\n")))
+}
+
+func TestWriterLogfmtDisablesColors(t *testing.T) {
+	assert := assert.New(t)
+
+	writer := NewWriter(bytes.NewBuffer(nil))
+	writer.SetUseAnsiColors(true)
+	assert.Equal(ColorBlue.Apply("foo"), writer.Colorize("foo", ColorBlue))
+
+	writer.SetOutputFormat(OutputFormatLogfmt)
+	assert.True(writer.UseAnsiColors())
+	assert.Equal("foo", writer.Colorize("foo", ColorBlue))
+	assert.Equal("200", writer.ColorizeByStatusCode(http.StatusOK, "200"))
+}
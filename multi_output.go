@@ -7,7 +7,9 @@ import (
 
 // NewMultiOutputFromEnvironment creates a new multiplexed stdout writer.
 func NewMultiOutputFromEnvironment() io.Writer {
-	primary := os.Stdout
+	primary := NewOutputTargetFromEnvironment(os.Stdout, EnvironmentVariableLogStdoutEvents, EnvironmentVariableLogStdoutFormat)
+	targets := []*OutputTarget{primary}
+
 	filePath := os.Getenv(EnvironmentVariableLogOutFile)
 	if len(filePath) > 0 {
 		secondary, err := NewFileOutputFromEnvironment(
@@ -15,18 +17,44 @@ func NewMultiOutputFromEnvironment() io.Writer {
 			EnvironmentVariableLogOutArchiveCompress,
 			EnvironmentVariableLogOutMaxSizeBytes,
 			EnvironmentVariableLogOutMaxArchive,
+			FileOutputRotationFromEnvironment(EnvironmentVariableLogOutRotate, EnvironmentVariableLogOutRotateTZ),
+			FileOutputArchiveNamingFromEnvironment(EnvironmentVariableLogOutArchiveNaming),
+			FileOutputRetentionFromEnvironment(EnvironmentVariableLogOutMaxAge, EnvironmentVariableLogOutMaxTotalBytes),
 		)
 		if err != nil {
 			panic(err)
 		}
-		return NewMultiOutput(primary, secondary)
+		targets = append(targets, NewOutputTargetFromEnvironment(secondary, EnvironmentVariableLogOutFileEvents, EnvironmentVariableLogOutFileFormat))
+	}
+
+	auditFilePath := os.Getenv(EnvironmentVariableLogAuditFile)
+	if len(auditFilePath) > 0 {
+		audit, err := NewFileOutputFromEnvironment(
+			EnvironmentVariableLogAuditFile,
+			EnvironmentVariableLogAuditArchiveCompress,
+			EnvironmentVariableLogAuditMaxSizeBytes,
+			EnvironmentVariableLogAuditMaxArchive,
+			FileOutputRotationFromEnvironment(EnvironmentVariableLogAuditRotate, EnvironmentVariableLogAuditRotateTZ),
+			FileOutputArchiveNamingFromEnvironment(EnvironmentVariableLogAuditArchiveNaming),
+			FileOutputRetentionFromEnvironment(EnvironmentVariableLogAuditMaxAge, EnvironmentVariableLogAuditMaxTotalBytes),
+		)
+		if err != nil {
+			panic(err)
+		}
+		targets = append(targets, NewOutputTargetFromEnvironment(audit, EnvironmentVariableLogAuditFileEvents, EnvironmentVariableLogAuditFileFormat))
+	}
+
+	if len(targets) == 1 && !primary.IsCustomized() {
+		return NewSyncOutput(os.Stdout)
 	}
-	return NewSyncOutput(primary)
+	return NewMultiOutputWithTargets(targets...)
 }
 
 // NewErrorMultiOutputFromEnvironment creates a new multiplexed stderr writer.
 func NewErrorMultiOutputFromEnvironment() io.Writer {
-	primary := os.Stderr
+	primary := NewOutputTargetFromEnvironment(os.Stderr, EnvironmentVariableLogStderrEvents, EnvironmentVariableLogStderrFormat)
+	targets := []*OutputTarget{primary}
+
 	filePath := os.Getenv(EnvironmentVariableLogErrFile)
 	if len(filePath) > 0 {
 		secondary, err := NewFileOutputFromEnvironment(
@@ -34,45 +62,110 @@ func NewErrorMultiOutputFromEnvironment() io.Writer {
 			EnvironmentVariableLogErrArchiveCompress,
 			EnvironmentVariableLogErrMaxSizeBytes,
 			EnvironmentVariableLogErrMaxArchive,
+			FileOutputRotationFromEnvironment(EnvironmentVariableLogErrRotate, EnvironmentVariableLogErrRotateTZ),
+			FileOutputArchiveNamingFromEnvironment(EnvironmentVariableLogErrArchiveNaming),
+			FileOutputRetentionFromEnvironment(EnvironmentVariableLogErrMaxAge, EnvironmentVariableLogErrMaxTotalBytes),
 		)
 		if err != nil {
 			panic(err)
 		}
-		return NewMultiOutput(primary, secondary)
+		targets = append(targets, NewOutputTargetFromEnvironment(secondary, EnvironmentVariableLogErrFileEvents, EnvironmentVariableLogErrFileFormat))
+	}
+
+	if len(targets) == 1 && !primary.IsCustomized() {
+		return NewSyncOutput(os.Stderr)
 	}
-	return NewSyncOutput(primary)
+	return NewMultiOutputWithTargets(targets...)
 }
 
 // NewMultiOutput creates a new MultiOutput that wraps an array of writers.
+// Each writer receives every record, formatted with the writer's formatter.
 func NewMultiOutput(outputs ...io.Writer) *MultiOutput {
+	targets := make([]*OutputTarget, len(outputs))
+	for x := 0; x < len(outputs); x++ {
+		targets[x] = NewOutputTarget(outputs[x], nil, nil)
+	}
+	return NewMultiOutputWithTargets(targets...)
+}
+
+// NewMultiOutputWithTargets creates a new MultiOutput that wraps an array of targets.
+func NewMultiOutputWithTargets(targets ...*OutputTarget) *MultiOutput {
 	return &MultiOutput{
-		outputs: outputs,
+		targets: targets,
 	}
 }
 
 // MultiOutput writes to many writers at once.
+// When used as a writer's output, each target can filter events and format records on its own.
 type MultiOutput struct {
-	outputs []io.Writer
+	targets []*OutputTarget
+}
+
+// Targets returns the output targets.
+func (mo *MultiOutput) Targets() []*OutputTarget {
+	return mo.targets
 }
 
-func (mo MultiOutput) Write(buffer []byte) (int, error) {
+// AddTarget adds an output target.
+// It should only be called before the output is in use.
+func (mo *MultiOutput) AddTarget(target *OutputTarget) {
+	mo.targets = append(mo.targets, target)
+}
+
+// Write writes a pre-formatted buffer to all of the targets, regardless of their event filters.
+func (mo *MultiOutput) Write(buffer []byte) (int, error) {
 	var written int
 	var err error
 
-	for x := 0; x < len(mo.outputs); x++ {
-		if mo.outputs[x] != nil {
-			written, err = mo.outputs[x].Write(buffer)
+	for x := 0; x < len(mo.targets); x++ {
+		if mo.targets[x] != nil && mo.targets[x].Output != nil {
+			written, err = mo.targets[x].Output.Write(buffer)
 		}
 	}
 	return written, err
 }
 
+// WriteRecord implements RecordWriter.
+// It writes a record to each target that has the record's event enabled, formatted with the target's formatter.
+func (mo *MultiOutput) WriteRecord(wr *Writer, record *Record) (int64, error) {
+	var written int64
+	var err error
+
+	for x := 0; x < len(mo.targets); x++ {
+		if mo.targets[x] != nil {
+			written, err = mo.targets[x].WriteRecord(wr, record)
+		}
+	}
+	return written, err
+}
+
+// Reopen reopens all of the inner writers (if they are Reopeners).
+func (mo *MultiOutput) Reopen() error {
+	var err error
+	var reopenErr error
+	for x := 0; x < len(mo.targets); x++ {
+		if mo.targets[x] == nil {
+			continue
+		}
+		if typed, isTyped := mo.targets[x].Output.(Reopener); isTyped {
+			reopenErr = typed.Reopen()
+			if reopenErr != nil {
+				err = reopenErr
+			}
+		}
+	}
+	return err
+}
+
 // Close closes all of the inner writers (if they are io.WriteClosers).
-func (mo MultiOutput) Close() error {
+func (mo *MultiOutput) Close() error {
 	var err error
 	var closeErr error
-	for x := 0; x < len(mo.outputs); x++ {
-		if typed, isTyped := mo.outputs[x].(io.Closer); isTyped {
+	for x := 0; x < len(mo.targets); x++ {
+		if mo.targets[x] == nil {
+			continue
+		}
+		if typed, isTyped := mo.targets[x].Output.(io.Closer); isTyped {
 			closeErr = typed.Close()
 			if closeErr != nil {
 				err = closeErr
@@ -0,0 +1,119 @@
+package logger
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	assert "github.com/blendlabs/go-assert"
+)
+
+func TestMultiOutputWriteRecordTargets(t *testing.T) {
+	assert := assert.New(t)
+
+	stdout := bytes.NewBuffer(nil)
+	file := bytes.NewBuffer(nil)
+	audit := bytes.NewBuffer(nil)
+
+	writer := NewWriter(NewMultiOutputWithTargets(
+		NewOutputTarget(stdout, NewEventFlagSet(EventInfo, EventError), nil),
+		NewOutputTarget(file, nil, &JSONFormatter{}),
+		NewOutputTarget(audit, NewEventFlagSet(EventWebRequest), &LogfmtFormatter{}),
+	))
+	writer.SetShowTimestamp(false)
+	writer.SetUseAnsiColors(false)
+
+	writer.PrintEventfWithTimeSource(SystemClock, EventInfo, ColorWhite, nil, "hello")
+	writer.PrintEventfWithTimeSource(SystemClock, EventDebug, ColorWhite, nil, "debug")
+	req := &http.Request{Method: "GET", URL: &url.URL{Path: "/"}, RemoteAddr: "1.2.3.4:5678"}
+	WriteRequest(writer, SystemClock, req, http.StatusOK, 512, time.Millisecond)
+
+	assert.Equal("[info] hello\n", stdout.String())
+	assert.Equal(`{"event":"info","message":"hello"}`+"\n"+
+		`{"event":"debug","message":"debug"}`+"\n"+
+		`{"event":"web.request","ip":"1.2.3.4","method":"GET","path":"/","status":200,"elapsed_ms":1,"bytes":512}`+"\n", file.String())
+	assert.Equal("event=web.request ip=1.2.3.4 method=GET path=/ status=200 elapsed_ms=1 bytes=512\n", audit.String())
+}
+
+func TestMultiOutputPlainMessagesIgnoreFilters(t *testing.T) {
+	assert := assert.New(t)
+
+	filtered := bytes.NewBuffer(nil)
+	writer := NewWriter(NewMultiOutputWithTargets(NewOutputTarget(filtered, NewEventFlagSet(EventError), nil)))
+	writer.SetShowTimestamp(false)
+
+	writer.Printf("plain %s", "message")
+	assert.Equal("plain message\n", filtered.String())
+}
+
+func TestMultiOutputWrite(t *testing.T) {
+	assert := assert.New(t)
+
+	first := bytes.NewBuffer(nil)
+	second := bytes.NewBuffer(nil)
+	mo := NewMultiOutput(first)
+	mo.AddTarget(NewOutputTarget(second, NewEventFlagSet(EventError), nil))
+	assert.Len(mo.Targets(), 2)
+
+	written, err := mo.Write([]byte("test"))
+	assert.Nil(err)
+	assert.Equal(4, written)
+	assert.Equal("test", first.String())
+	assert.Equal("test", second.String())
+}
+
+func TestNewOutputTargetFromEnvironment(t *testing.T) {
+	assert := assert.New(t)
+
+	defer os.Unsetenv(EnvironmentVariableLogOutFileEvents)
+	defer os.Unsetenv(EnvironmentVariableLogOutFileFormat)
+
+	target := NewOutputTargetFromEnvironment(ioutil.Discard, EnvironmentVariableLogOutFileEvents, EnvironmentVariableLogOutFileFormat)
+	assert.False(target.IsCustomized())
+	assert.True(target.IsEnabled(EventDebug))
+
+	os.Setenv(EnvironmentVariableLogOutFileEvents, "web.request")
+	os.Setenv(EnvironmentVariableLogOutFileFormat, "json")
+	target = NewOutputTargetFromEnvironment(ioutil.Discard, EnvironmentVariableLogOutFileEvents, EnvironmentVariableLogOutFileFormat)
+	assert.True(target.IsCustomized())
+	assert.True(target.IsEnabled(EventWebRequest))
+	assert.False(target.IsEnabled(EventDebug))
+	_, isJSON := target.Formatter.(*JSONFormatter)
+	assert.True(isJSON)
+}
+
+func TestNewMultiOutputFromEnvironmentTargets(t *testing.T) {
+	assert := assert.New(t)
+
+	outFile := UUIDv4()
+	auditFile := UUIDv4()
+	defer os.Remove(outFile)
+	defer os.Remove(auditFile)
+
+	os.Setenv(EnvironmentVariableLogOutFile, outFile)
+	os.Setenv(EnvironmentVariableLogOutFileFormat, "json")
+	os.Setenv(EnvironmentVariableLogAuditFile, auditFile)
+	os.Setenv(EnvironmentVariableLogAuditFileEvents, "web.request")
+	defer os.Unsetenv(EnvironmentVariableLogOutFile)
+	defer os.Unsetenv(EnvironmentVariableLogOutFileFormat)
+	defer os.Unsetenv(EnvironmentVariableLogAuditFile)
+	defer os.Unsetenv(EnvironmentVariableLogAuditFileEvents)
+
+	output, isMultiOutput := NewMultiOutputFromEnvironment().(*MultiOutput)
+	assert.True(isMultiOutput)
+
+	targets := output.Targets()
+	assert.Len(targets, 3)
+	defer targets[1].Output.(*FileOutput).Close()
+	defer targets[2].Output.(*FileOutput).Close()
+	assert.Equal(os.Stdout, targets[0].Output)
+	assert.False(targets[0].IsCustomized())
+	_, isJSON := targets[1].Formatter.(*JSONFormatter)
+	assert.True(isJSON)
+	assert.True(targets[2].IsEnabled(EventWebRequest))
+	assert.False(targets[2].IsEnabled(EventInfo))
+}
@@ -0,0 +1,87 @@
+package logger
+
+import (
+	"io"
+	"os"
+)
+
+// RecordWriter is an output that formats and writes records itself.
+// Writers hand records to outputs that implement it instead of formatting them first.
+type RecordWriter interface {
+	WriteRecord(wr *Writer, record *Record) (int64, error)
+}
+
+// NewOutputTarget returns a new output target.
+// A nil event set writes all events, a nil formatter uses the writer's formatter.
+func NewOutputTarget(output io.Writer, events *EventFlagSet, formatter Formatter) *OutputTarget {
+	return &OutputTarget{
+		Output:    output,
+		Events:    events,
+		Formatter: formatter,
+	}
+}
+
+// NewOutputTargetFromEnvironment returns a new output target with its event filter and format read from the given environment variable names.
+// The events variable is a csv of event flags (as with `LOG_EVENTS`), the format variable is an output format name.
+func NewOutputTargetFromEnvironment(output io.Writer, eventsVar, formatVar string) *OutputTarget {
+	target := NewOutputTarget(output, nil, nil)
+	if events := os.Getenv(eventsVar); len(events) > 0 {
+		target.Events = NewEventFlagSetFromCSV(events)
+	}
+	if format := os.Getenv(formatVar); len(format) > 0 {
+		target.Formatter = NewFormatter(ParseOutputFormat(format))
+	}
+	return target
+}
+
+// OutputTarget is an output with its own event filter and formatter.
+// The filter only narrows the events the agent is already configured to write.
+type OutputTarget struct {
+	Output    io.Writer
+	Events    *EventFlagSet
+	Formatter Formatter
+}
+
+// IsCustomized returns if the target has an event filter or formatter set.
+func (ot *OutputTarget) IsCustomized() bool {
+	return ot.Events != nil || ot.Formatter != nil
+}
+
+// IsEnabled returns if the target writes a given event.
+// Records without an event (plain messages) are always written.
+func (ot *OutputTarget) IsEnabled(event EventFlag) bool {
+	if ot.Events == nil || len(event) == 0 {
+		return true
+	}
+	return ot.Events.IsEnabled(event)
+}
+
+// WriteRecord implements RecordWriter.
+func (ot *OutputTarget) WriteRecord(wr *Writer, record *Record) (int64, error) {
+	if ot.Output == nil {
+		return 0, nil
+	}
+	if !ot.IsEnabled(record.Event) {
+		return 0, nil
+	}
+	if typed, isTyped := ot.Output.(RecordWriter); isTyped && ot.Formatter == nil {
+		return typed.WriteRecord(wr, record)
+	}
+
+	if ot.Formatter == nil {
+		return formatRecordTo(wr, ot.Output, wr.Formatter(), record)
+	}
+	return formatRecordTo(wr, ot.Output, ot.Formatter, record)
+}
+
+// formatRecordTo formats a record with a given formatter into a pooled buffer and writes it, newline terminated, to a writer.
+func formatRecordTo(wr *Writer, w io.Writer, formatter Formatter, record *Record) (int64, error) {
+	buf := wr.GetBuffer()
+	defer wr.PutBuffer(buf)
+
+	if err := formatter.Format(wr, buf, record); err != nil {
+		return 0, err
+	}
+	buf.WriteRune(RuneNewline)
+	return buf.WriteTo(w)
+}
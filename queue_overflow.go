@@ -0,0 +1,329 @@
+package logger
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blendlabs/go-workqueue"
+)
+
+const (
+	// EventQueueOverflow is an event that fires periodically when the agent has dropped events because its queue was full.
+	// It is written unless the agent's events disable it explicitly.
+	EventQueueOverflow EventFlag = "queue_overflow"
+)
+
+var (
+	// DefaultQueueOverflowReportInterval is how often the agent reports dropped events.
+	DefaultQueueOverflowReportInterval = 10 * time.Second
+
+	// DefaultQueueOverflowTimeout is how long `QueueOverflowBlockWithTimeout` blocks for when set from the environment.
+	DefaultQueueOverflowTimeout = 100 * time.Millisecond
+)
+
+// QueueOverflowPolicy is what the agent does with an event when its queue is full.
+type QueueOverflowPolicy string
+
+const (
+	// QueueOverflowBlock blocks the caller until there is room in the queue (the default).
+	QueueOverflowBlock QueueOverflowPolicy = "block"
+	// QueueOverflowDropNewest drops the event being queued.
+	QueueOverflowDropNewest QueueOverflowPolicy = "drop_newest"
+	// QueueOverflowDropOldest drops the oldest event in the queue to make room for the event being queued.
+	QueueOverflowDropOldest QueueOverflowPolicy = "drop_oldest"
+	// QueueOverflowBlockWithTimeout blocks the caller until there is room in the queue, dropping the event being queued after a timeout.
+	QueueOverflowBlockWithTimeout QueueOverflowPolicy = "block_timeout"
+)
+
+// ParseQueueOverflowPolicy returns the queue overflow policy for a given name, defaulting to `QueueOverflowBlock`.
+func ParseQueueOverflowPolicy(value string) QueueOverflowPolicy {
+	switch policy := QueueOverflowPolicy(strings.ToLower(strings.TrimSpace(value))); policy {
+	case QueueOverflowDropNewest, QueueOverflowDropOldest, QueueOverflowBlockWithTimeout:
+		return policy
+	default:
+		return QueueOverflowBlock
+	}
+}
+
+// newOverflowQueue returns a new overflow queue in front of a work queue.
+func newOverflowQueue(queue *workqueue.Queue, policy QueueOverflowPolicy, timeout time.Duration) *overflowQueue {
+	oq := &overflowQueue{
+		queue:      queue,
+		policy:     policy,
+		timeout:    timeout,
+		slots:      make(chan struct{}, queue.MaxWorkItems()),
+		dropped:    map[EventFlag]int64{},
+		unreported: map[EventFlag]int64{},
+	}
+	oq.turnCond = sync.NewCond(&oq.turnLock)
+	return oq
+}
+
+// overflowQueue admits actions to a work queue according to an overflow policy.
+// Actions wait in a pending list, and each has a token in the work queue that runs the oldest pending action.
+// This keeps the work queue from ever blocking, and lets the oldest pending action be replaced.
+type overflowQueue struct {
+	queue   *workqueue.Queue
+	policy  QueueOverflowPolicy
+	timeout time.Duration
+
+	slots       chan struct{}
+	pendingLock sync.Mutex
+	pending     []overflowQueueItem
+
+	// ordered writes take a ticket (under the pending lock) as they're taken from the pending list, and run in ticket order.
+	ordered    bool
+	nextTicket uint64
+	turnLock   sync.Mutex
+	turnCond   *sync.Cond
+	turn       uint64
+
+	droppedLock sync.Mutex
+	dropped     map[EventFlag]int64
+	unreported  map[EventFlag]int64
+
+	spill       *spillFile
+	spillReplay func(*spillRecord) error
+
+	// onDiscard is called for each action that won't run, because it was dropped or spilled.
+	onDiscard func()
+}
+
+type overflowQueueItem struct {
+	kind      spillKind
+	eventFlag EventFlag
+	action    func(...interface{}) error
+	args      []interface{}
+}
+
+// Enqueue queues an action for a given event, returning if the action was queued.
+func (oq *overflowQueue) Enqueue(eventFlag EventFlag, action func(...interface{}) error, args ...interface{}) bool {
+	return oq.EnqueueWrite(spillKindNone, eventFlag, action, args...)
+}
+
+// EnqueueWrite queues an action that is a given kind of write, returning if the action was queued (or spilled).
+func (oq *overflowQueue) EnqueueWrite(kind spillKind, eventFlag EventFlag, action func(...interface{}) error, args ...interface{}) bool {
+	item := overflowQueueItem{kind: kind, eventFlag: eventFlag, action: action, args: args}
+
+	switch oq.policy {
+	case QueueOverflowSpill:
+		if !oq.tryAcquire() {
+			if oq.spillItem(item) {
+				oq.discarded()
+				return true
+			}
+			oq.discard(item)
+			return false
+		}
+	case QueueOverflowDropNewest:
+		if !oq.tryAcquire() {
+			oq.discard(item)
+			return false
+		}
+	case QueueOverflowBlockWithTimeout:
+		if !oq.tryAcquire() {
+			timeout := time.NewTimer(oq.timeout)
+			defer timeout.Stop()
+			select {
+			case oq.slots <- struct{}{}:
+			case <-timeout.C:
+				oq.discard(item)
+				return false
+			}
+		}
+	case QueueOverflowDropOldest:
+		if !oq.tryAcquire() {
+			if oq.replaceOldest(item) {
+				return true
+			}
+			// with nothing pending, every slot is held by an action that was just taken to run, and releases it before running.
+			oq.slots <- struct{}{}
+		}
+	default:
+		oq.slots <- struct{}{}
+	}
+
+	oq.pendingLock.Lock()
+	oq.pending = append(oq.pending, item)
+	oq.pendingLock.Unlock()
+	oq.queue.Enqueue(oq.runNext)
+	return true
+}
+
+// Dropped returns the number of actions dropped for each event.
+func (oq *overflowQueue) Dropped() map[EventFlag]int64 {
+	oq.droppedLock.Lock()
+	defer oq.droppedLock.Unlock()
+	return copyEventCounts(oq.dropped)
+}
+
+// Unreported returns and resets the number of actions dropped for each event since it was last called.
+func (oq *overflowQueue) Unreported() map[EventFlag]int64 {
+	oq.droppedLock.Lock()
+	defer oq.droppedLock.Unlock()
+	unreported := oq.unreported
+	oq.unreported = map[EventFlag]int64{}
+	return unreported
+}
+
+// tryAcquire takes a slot in the queue if one is free.
+func (oq *overflowQueue) tryAcquire() bool {
+	select {
+	case oq.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// replaceOldest drops the oldest pending action in favor of a given item.
+// It returns false if there are no pending actions to drop.
+func (oq *overflowQueue) replaceOldest(item overflowQueueItem) bool {
+	oq.pendingLock.Lock()
+	if len(oq.pending) == 0 {
+		oq.pendingLock.Unlock()
+		return false
+	}
+	oldest := oq.pending[0]
+	oq.pending = append(oq.pending[1:], item)
+	oq.pendingLock.Unlock()
+
+	oq.discard(oldest)
+	return true
+}
+
+// runNext runs the oldest pending action.
+func (oq *overflowQueue) runNext(_ ...interface{}) error {
+	oq.pendingLock.Lock()
+	if len(oq.pending) == 0 {
+		oq.pendingLock.Unlock()
+		return nil
+	}
+	next := oq.pending[0]
+	oq.pending[0] = overflowQueueItem{}
+	oq.pending = oq.pending[1:]
+	idle := len(oq.pending) == 0
+	ordered := oq.ordered && next.kind != spillKindNone
+	var ticket uint64
+	if ordered {
+		ticket = oq.nextTicket
+		oq.nextTicket++
+	}
+	oq.pendingLock.Unlock()
+	<-oq.slots
+
+	if ordered {
+		oq.waitForTurn(ticket)
+		defer oq.finishTurn()
+	}
+	err := next.action(next.args...)
+	if idle {
+		oq.ReplaySpilled()
+	}
+	return err
+}
+
+// ReplaySpilled replays the records in the spillover file, if there is one.
+func (oq *overflowQueue) ReplaySpilled() error {
+	if oq.spill == nil || !oq.spill.HasRecords() {
+		return nil
+	}
+	_, err := oq.spill.Replay(oq.spillReplay)
+	return err
+}
+
+// SetOrdered sets if writes run one at a time, in the order they were queued.
+// Listener triggers still run in parallel.
+func (oq *overflowQueue) SetOrdered(ordered bool) {
+	oq.pendingLock.Lock()
+	oq.ordered = ordered
+	oq.pendingLock.Unlock()
+}
+
+// waitForTurn waits until the writes with earlier tickets have run.
+func (oq *overflowQueue) waitForTurn(ticket uint64) {
+	oq.turnLock.Lock()
+	for oq.turn != ticket {
+		oq.turnCond.Wait()
+	}
+	oq.turnLock.Unlock()
+}
+
+// finishTurn lets the write with the next ticket run.
+func (oq *overflowQueue) finishTurn() {
+	oq.turnLock.Lock()
+	oq.turn++
+	oq.turnLock.Unlock()
+	oq.turnCond.Broadcast()
+}
+
+// SpillPending moves the pending writes to the spillover file, dropping any other pending actions.
+func (oq *overflowQueue) SpillPending() {
+	oq.pendingLock.Lock()
+	pending := oq.pending
+	oq.pending = nil
+	oq.pendingLock.Unlock()
+
+	for _, item := range pending {
+		if oq.spillItem(item) {
+			oq.discarded()
+		} else {
+			oq.discard(item)
+		}
+	}
+}
+
+// spillItem appends a write to the spillover file, returning false if the item isn't a write or couldn't be spilled.
+func (oq *overflowQueue) spillItem(item overflowQueueItem) bool {
+	if oq.spill == nil || item.kind == spillKindNone {
+		return false
+	}
+	record, err := newSpillRecord(item.kind, item.args...)
+	if err != nil {
+		return false
+	}
+	return oq.spill.Append(record) == nil
+}
+
+// discard drops an action that was handed to the queue.
+func (oq *overflowQueue) discard(item overflowQueueItem) {
+	oq.drop(item.eventFlag)
+	oq.discarded()
+}
+
+func (oq *overflowQueue) discarded() {
+	if oq.onDiscard != nil {
+		oq.onDiscard()
+	}
+}
+
+func (oq *overflowQueue) drop(eventFlag EventFlag) {
+	oq.droppedLock.Lock()
+	oq.dropped[eventFlag]++
+	oq.unreported[eventFlag]++
+	oq.droppedLock.Unlock()
+}
+
+func copyEventCounts(counts map[EventFlag]int64) map[EventFlag]int64 {
+	copied := make(map[EventFlag]int64, len(counts))
+	for eventFlag, count := range counts {
+		copied[eventFlag] = count
+	}
+	return copied
+}
+
+// eventCountFields returns event counts as fields, sorted by event.
+func eventCountFields(counts map[EventFlag]int64) (Fields, int64) {
+	var fields Fields
+	var total int64
+	for eventFlag, count := range counts {
+		fields = append(fields, Field{Key: string(eventFlag), Value: count})
+		total += count
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Key < fields[j].Key
+	})
+	return fields, total
+}
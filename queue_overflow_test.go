@@ -0,0 +1,165 @@
+package logger
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	assert "github.com/blendlabs/go-assert"
+	"github.com/blendlabs/go-workqueue"
+)
+
+// blockedQueue is a single worker queue with room for two items, whose first action blocks until released.
+type blockedQueue struct {
+	queue    *workqueue.Queue
+	started  chan struct{}
+	release  chan struct{}
+	ranLock  sync.Mutex
+	ran      []string
+	finished sync.WaitGroup
+}
+
+func newBlockedQueue() *blockedQueue {
+	queue := workqueue.NewWithWorkers(1)
+	queue.SetMaxWorkItems(2)
+	queue.Start()
+	return &blockedQueue{
+		queue:   queue,
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+}
+
+func (bq *blockedQueue) block(args ...interface{}) error {
+	close(bq.started)
+	<-bq.release
+	return bq.record(args...)
+}
+
+func (bq *blockedQueue) record(args ...interface{}) error {
+	bq.ranLock.Lock()
+	bq.ran = append(bq.ran, args[0].(string))
+	bq.ranLock.Unlock()
+	bq.finished.Done()
+	return nil
+}
+
+func TestParseQueueOverflowPolicy(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(QueueOverflowDropNewest, ParseQueueOverflowPolicy("DROP_NEWEST"))
+	assert.Equal(QueueOverflowDropOldest, ParseQueueOverflowPolicy("drop_oldest"))
+	assert.Equal(QueueOverflowBlockWithTimeout, ParseQueueOverflowPolicy("block_timeout"))
+	assert.Equal(QueueOverflowBlock, ParseQueueOverflowPolicy(""))
+	assert.Equal(QueueOverflowBlock, ParseQueueOverflowPolicy("sometimes"))
+}
+
+func TestOverflowQueueDropNewest(t *testing.T) {
+	assert := assert.New(t)
+
+	bq := newBlockedQueue()
+	defer bq.queue.Close()
+	oq := newOverflowQueue(bq.queue, QueueOverflowDropNewest, 0)
+
+	bq.finished.Add(3)
+	assert.True(oq.Enqueue(EventInfo, bq.block, "first"))
+	<-bq.started
+	assert.True(oq.Enqueue(EventInfo, bq.record, "second"))
+	assert.True(oq.Enqueue(EventInfo, bq.record, "third"))
+	assert.False(oq.Enqueue(EventWebRequest, bq.record, "fourth"))
+	close(bq.release)
+	bq.finished.Wait()
+
+	assert.Equal([]string{"first", "second", "third"}, bq.ran)
+	assert.Equal(map[EventFlag]int64{EventWebRequest: 1}, oq.Dropped())
+}
+
+func TestOverflowQueueDropOldest(t *testing.T) {
+	assert := assert.New(t)
+
+	bq := newBlockedQueue()
+	defer bq.queue.Close()
+	oq := newOverflowQueue(bq.queue, QueueOverflowDropOldest, 0)
+
+	bq.finished.Add(3)
+	assert.True(oq.Enqueue(EventInfo, bq.block, "first"))
+	<-bq.started
+	assert.True(oq.Enqueue(EventWebRequest, bq.record, "second"))
+	assert.True(oq.Enqueue(EventInfo, bq.record, "third"))
+	assert.True(oq.Enqueue(EventInfo, bq.record, "fourth"))
+	close(bq.release)
+	bq.finished.Wait()
+
+	assert.Equal([]string{"first", "third", "fourth"}, bq.ran)
+	assert.Equal(map[EventFlag]int64{EventWebRequest: 1}, oq.Dropped())
+}
+
+func TestOverflowQueueBlockWithTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	bq := newBlockedQueue()
+	defer bq.queue.Close()
+	oq := newOverflowQueue(bq.queue, QueueOverflowBlockWithTimeout, time.Millisecond)
+
+	bq.finished.Add(3)
+	assert.True(oq.Enqueue(EventInfo, bq.block, "first"))
+	<-bq.started
+	assert.True(oq.Enqueue(EventInfo, bq.record, "second"))
+	assert.True(oq.Enqueue(EventInfo, bq.record, "third"))
+	assert.False(oq.Enqueue(EventInfo, bq.record, "fourth"))
+	close(bq.release)
+	bq.finished.Wait()
+
+	assert.Equal([]string{"first", "second", "third"}, bq.ran)
+	assert.Equal(map[EventFlag]int64{EventInfo: 1}, oq.Dropped())
+}
+
+func TestAgentReportQueueOverflow(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := bytes.NewBuffer(nil)
+	writer := NewWriter(buffer)
+	writer.SetShowTimestamp(false)
+	writer.SetUseAnsiColors(false)
+	agent := NewWithWriter(NewEventFlagSet(EventInfo), writer)
+	defer agent.Close()
+
+	agent.SetQueueOverflowPolicy(QueueOverflowDropNewest, 0)
+	assert.Equal(QueueOverflowDropNewest, agent.QueueOverflowPolicy())
+	assert.False(agent.IsEnabled(EventQueueOverflow))
+
+	agent.ReportQueueOverflow()
+	assert.Empty(buffer.String())
+
+	agent.overflowQueue.drop(EventInfo)
+	agent.overflowQueue.drop(EventInfo)
+	agent.overflowQueue.drop(EventWebRequest)
+	agent.ReportQueueOverflow()
+	assert.Contains(buffer.String(), "[queue_overflow] dropped 3 queued events info=2 web.request=1")
+	assert.Equal(map[EventFlag]int64{EventInfo: 2, EventWebRequest: 1}, agent.DroppedEvents())
+
+	buffer.Reset()
+	agent.ReportQueueOverflow()
+	assert.Empty(buffer.String())
+}
+
+func TestAgentReportQueueOverflowDisabled(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := bytes.NewBuffer(nil)
+	writer := NewWriter(buffer)
+	writer.SetShowTimestamp(false)
+	writer.SetUseAnsiColors(false)
+	events := NewEventFlagSet(EventInfo)
+	events.Disable(EventQueueOverflow)
+	agent := NewWithWriter(events, writer)
+	defer agent.Close()
+
+	agent.SetQueueOverflowPolicy(QueueOverflowDropNewest, 0)
+	assert.Len(events.flags, 2, "the policy shouldn't change the events")
+
+	agent.overflowQueue.drop(EventInfo)
+	agent.ReportQueueOverflow()
+	assert.Empty(buffer.String())
+}
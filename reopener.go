@@ -0,0 +1,48 @@
+package logger
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Reopener is an output that can reopen its underlying resources, e.g. a file that has been moved.
+type Reopener interface {
+	Reopen() error
+}
+
+// ReopenOnSignal reopens the agent's outputs whenever the process receives one of the given signals (SIGHUP by default).
+// It is meant for files rotated by an external tool like logrotate; errors reopening are written to the agent's error output.
+// It returns a function that stops listening for the signals, and waits for a reopen in progress to finish.
+func (da *Agent) ReopenOnSignal(signals ...os.Signal) (stop func()) {
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGHUP}
+	}
+
+	received := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	signal.Notify(received, signals...)
+
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-received:
+				if writer := da.Writer(); writer != nil {
+					if err := writer.Reopen(); err != nil {
+						da.Error(err)
+					}
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(received)
+		close(done)
+		<-stopped
+	}
+}
@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	assert "github.com/blendlabs/go-assert"
+)
+
+type reopenCounter struct {
+	bytes.Buffer
+	reopened int
+}
+
+func (rc *reopenCounter) Reopen() error {
+	rc.reopened++
+	return nil
+}
+
+func TestWriterReopen(t *testing.T) {
+	assert := assert.New(t)
+
+	stdout := &reopenCounter{}
+	stderr := &reopenCounter{}
+	other := &reopenCounter{}
+	writer := NewWriterWithError(NewMultiOutput(stdout, new(bytes.Buffer), other), stderr)
+
+	assert.Nil(writer.Reopen())
+	assert.Equal(1, stdout.reopened)
+	assert.Equal(1, other.reopened)
+	assert.Equal(1, stderr.reopened)
+}
+
+func TestAgentReopenOnSignal(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+
+	tempFile := filepath.Join(tempDir, "app.log")
+	fo, err := NewFileOutput(tempFile, false, FileOutputUnlimitedSize, FileOutputUnlimitedArchiveFiles)
+	assert.Nil(err)
+
+	agent := All(NewWriter(fo))
+	defer agent.Close()
+
+	stop := agent.ReopenOnSignal(syscall.SIGHUP)
+	defer stop()
+
+	assert.Nil(os.Rename(tempFile, tempFile+".moved"))
+	process, err := os.FindProcess(os.Getpid())
+	assert.Nil(err)
+	assert.Nil(process.Signal(syscall.SIGHUP))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err = os.Stat(tempFile); err == nil {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	assert.Nil(err)
+}
@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+)
+
+const (
+	// DefaultRequestIDHeader is the header the middleware reads request ids from, and writes them to.
+	DefaultRequestIDHeader = "X-Request-Id"
+
+	// maxRequestIDLength is the longest incoming request id that is trusted; longer ids are replaced.
+	maxRequestIDLength = 128
+)
+
+// requestIDKey is the context key a request id is stored under.
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of a context that carries a request id.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request id carried by a context, or an empty string if it doesn't carry one.
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if requestID, ok := ctx.Value(requestIDKey{}).(string); ok {
+		return requestID
+	}
+	return ""
+}
+
+// requestIDFields returns the `request_id` field for a request, or nil if its context doesn't carry a request id.
+func requestIDFields(req *http.Request) Fields {
+	if req == nil {
+		return nil
+	}
+	if requestID := RequestID(req.Context()); len(requestID) > 0 {
+		return Fields{{Key: FieldRequestID, Value: requestID}}
+	}
+	return nil
+}
+
+// isValidRequestID returns if an incoming request id is safe to log as is:
+// it isn't empty or too long, and is made of printable ascii without spaces.
+func isValidRequestID(requestID string) bool {
+	if len(requestID) == 0 || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for x := 0; x < len(requestID); x++ {
+		if requestID[x] <= ' ' || requestID[x] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,100 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	assert "github.com/blendlabs/go-assert"
+)
+
+func TestRequestIDContext(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Empty(RequestID(context.Background()))
+	ctx := WithRequestID(context.Background(), "abc123")
+	assert.Equal("abc123", RequestID(ctx))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	assert.Nil(requestIDFields(req))
+	assert.Equal(NewFields(FieldRequestID, "abc123"), requestIDFields(req.WithContext(ctx)))
+}
+
+func TestIsValidRequestID(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(isValidRequestID("abc123"))
+	assert.True(isValidRequestID(UUIDv4()))
+	assert.False(isValidRequestID(""))
+	assert.False(isValidRequestID("abc 123"))
+	assert.False(isValidRequestID("abc\n[error] injected"))
+	assert.False(isValidRequestID(strings.Repeat("a", maxRequestIDLength+1)))
+}
+
+func TestMiddlewareRequestID(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := bytes.NewBuffer(nil)
+	writer := NewWriter(buffer)
+	writer.SetShowTimestamp(false)
+	writer.SetUseAnsiColors(false)
+	da := NewWithWriter(NewEventFlagSet(EventWebRequestStart, EventWebRequest, EventWebRequestPostBody, EventWebResponse, EventError), writer)
+	defer da.Close()
+	da.AddEventListener(EventWebRequestStart, NewRequestStartListener(func(wr *Writer, ts TimeSource, req *http.Request) {
+		WriteRequestStart(wr, ts, req)
+	}))
+	da.AddEventListener(EventWebRequest, NewRequestListener(func(wr *Writer, ts TimeSource, req *http.Request, statusCode, contentLengthBytes int, elapsed time.Duration) {
+		WriteRequest(wr, ts, req, statusCode, contentLengthBytes, elapsed)
+	}))
+	da.AddEventListener(EventWebRequestPostBody, NewRequestBodyListener(func(wr *Writer, ts TimeSource, body []byte, fields Fields) {
+		WriteRequestBody(wr, ts, body, fields...)
+	}))
+	da.AddEventListener(EventWebResponse, NewResponseListener(func(wr *Writer, ts TimeSource, body []byte, fields Fields) {
+		WriteResponseBody(wr, ts, body, fields...)
+	}))
+
+	middleware := NewMiddleware(da)
+	middleware.SetCaptureRequestBody(64)
+	middleware.SetCaptureResponseBody(64)
+	var requestID string
+	handler := middleware.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		requestID = RequestID(req.Context())
+		ioutil.ReadAll(req.Body)
+		da.ErrorWithReq(fmt.Errorf("this is an error"), req)
+		FromContext(req.Context()).Infof("not enabled")
+		res.WriteHeader(http.StatusOK)
+		res.Write([]byte("created"))
+	})
+
+	req := httptest.NewRequest("POST", "/users", strings.NewReader("name=bailey"))
+	req.Header.Set(DefaultRequestIDHeader, "abc123")
+	res := httptest.NewRecorder()
+	handler(res, req)
+	assert.Nil(da.Flush(context.Background()))
+
+	assert.Equal("abc123", requestID)
+	assert.Equal("abc123", res.Header().Get(DefaultRequestIDHeader))
+	output := buffer.String()
+	assert.Contains(output, "[web.request.start] 192.0.2.1 POST /users request_id=abc123\n")
+	assert.Contains(output, "[web.request] 192.0.2.1 POST /users 200")
+	assert.Contains(output, "request_id=abc123\n")
+	assert.Contains(output, "[web.request.postbody] name=bailey request_id=abc123\n")
+	assert.Contains(output, "[web.response] created request_id=abc123\n")
+	assert.Contains(output, "[error] this is an error request_id=abc123\n")
+	assert.Equal(5, strings.Count(output, "request_id=abc123"))
+
+	req = httptest.NewRequest("GET", "/users", nil)
+	req.Header.Set(DefaultRequestIDHeader, "not a valid id")
+	res = httptest.NewRecorder()
+	handler(res, req)
+	assert.Nil(da.Flush(context.Background()))
+	assert.NotEqual("not a valid id", requestID)
+	assert.True(isValidRequestID(requestID))
+	assert.Equal(requestID, res.Header().Get(DefaultRequestIDHeader))
+}
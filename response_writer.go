@@ -1,6 +1,11 @@
 package logger
 
-import "net/http"
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"time"
+)
 
 // ResponseWrapper is a type that wraps a response.
 type ResponseWrapper interface {
@@ -11,20 +16,35 @@ type ResponseWrapper interface {
 func NewResponseWriter(w http.ResponseWriter) *ResponseWriter {
 	return &ResponseWriter{
 		innerResponse: w,
+		started:       time.Now(),
 	}
 }
 
-// ResponseWriter a better response writer
+// ResponseWriter a better response writer.
+// It records the status code, content length and time to first byte of a response.
+// Hand handlers `Passthrough()` rather than the response writer itself, so they can still use the optional interfaces
+// (`http.Flusher`, `http.Hijacker` and so on) of the inner writer.
 type ResponseWriter struct {
 	innerResponse http.ResponseWriter
 	statusCode    int
 	contentLength int
+	started       time.Time
+	headerWritten time.Time
+
+	// body holds the start of the response, up to bodyLimit bytes, if the response body is being captured.
+	body      *bytes.Buffer
+	bodyLimit int
 }
 
 // Write writes the data to the response.
+// If the status code hasn't been written it is written as `200 OK`, as the inner writer will.
 func (rw *ResponseWriter) Write(b []byte) (int, error) {
+	rw.writeImplicitHeader()
 	bytesWritten, err := rw.innerResponse.Write(b)
 	rw.contentLength = rw.contentLength + bytesWritten
+	if rw.body != nil && bytesWritten > 0 {
+		writeBounded(rw.body, rw.bodyLimit, b[:bytesWritten])
+	}
 	return bytesWritten, err
 }
 
@@ -34,22 +54,43 @@ func (rw *ResponseWriter) Header() http.Header {
 }
 
 // WriteHeader is actually a terrible name and this writes the status code.
+// Informational (1xx) status codes other than `101 Switching Protocols` are passed through without being recorded,
+// as the final status code is still to come.
 func (rw *ResponseWriter) WriteHeader(code int) {
-	rw.statusCode = code
+	if rw.statusCode == 0 && (code < 100 || code > 199 || code == http.StatusSwitchingProtocols) {
+		rw.statusCode = code
+		rw.headerWritten = time.Now()
+	}
 	rw.innerResponse.WriteHeader(code)
 }
 
+// writeImplicitHeader records the `200 OK` the inner writer writes if the response is written to before the status code is.
+func (rw *ResponseWriter) writeImplicitHeader() {
+	if rw.statusCode == 0 {
+		rw.statusCode = http.StatusOK
+		rw.headerWritten = time.Now()
+	}
+}
+
 // InnerWriter returns the backing writer.
 func (rw *ResponseWriter) InnerWriter() http.ResponseWriter {
 	return rw.innerResponse
 }
 
-// Flush is a no op on raw response writers.
-func (rw *ResponseWriter) Flush() error {
-	return nil
+// Unwrap returns the backing writer, for `http.ResponseController`.
+func (rw *ResponseWriter) Unwrap() http.ResponseWriter {
+	return rw.innerResponse
+}
+
+// Flush flushes the response to the client if the inner writer is an `http.Flusher`, and is a no op otherwise.
+func (rw *ResponseWriter) Flush() {
+	if flusher, ok := rw.innerResponse.(http.Flusher); ok {
+		rw.writeImplicitHeader()
+		flusher.Flush()
+	}
 }
 
-// StatusCode returns the status code.
+// StatusCode returns the status code, or 0 if nothing has been written.
 func (rw *ResponseWriter) StatusCode() int {
 	return rw.statusCode
 }
@@ -58,3 +99,53 @@ func (rw *ResponseWriter) StatusCode() int {
 func (rw *ResponseWriter) ContentLength() int {
 	return rw.contentLength
 }
+
+// TimeToFirstByte returns how long after the response writer was created the status code was written,
+// or 0 if it hasn't been written.
+func (rw *ResponseWriter) TimeToFirstByte() time.Duration {
+	if rw.headerWritten.IsZero() {
+		return 0
+	}
+	return rw.headerWritten.Sub(rw.started)
+}
+
+// captureBody sets the response writer to keep up to a given number of bytes of the response body.
+func (rw *ResponseWriter) captureBody(maxBytes int) {
+	rw.body = bytes.NewBuffer(nil)
+	rw.bodyLimit = maxBytes
+}
+
+// capturedBody returns the captured start of the response body, if it is being captured.
+func (rw *ResponseWriter) capturedBody() []byte {
+	if rw.body == nil {
+		return nil
+	}
+	return rw.body.Bytes()
+}
+
+// readFrom copies a reader to the response with the inner writer's `io.ReaderFrom`, so it can use sendfile and the like.
+// Captured responses are copied through `Write` instead.
+func (rw *ResponseWriter) readFrom(r io.Reader) (int64, error) {
+	if rw.body != nil {
+		return io.Copy(writerOnly{rw}, r)
+	}
+	rw.writeImplicitHeader()
+	bytesWritten, err := rw.innerResponse.(io.ReaderFrom).ReadFrom(r)
+	rw.contentLength = rw.contentLength + int(bytesWritten)
+	return bytesWritten, err
+}
+
+// writerOnly hides every method of a writer but `Write`, so `io.Copy` doesn't loop back into `ReadFrom`.
+type writerOnly struct {
+	io.Writer
+}
+
+// writeBounded writes as much of a set of bytes to a buffer as fits within a limit.
+func writeBounded(buffer *bytes.Buffer, limit int, b []byte) {
+	if remaining := limit - buffer.Len(); remaining > 0 {
+		if len(b) > remaining {
+			b = b[:remaining]
+		}
+		buffer.Write(b)
+	}
+}
@@ -0,0 +1,279 @@
+package logger
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+)
+
+const (
+	supportsFlusher = 1 << iota
+	supportsHijacker
+	supportsPusher
+	supportsReaderFrom
+	supportsCloseNotifier
+)
+
+// passthroughWriter is the part of a response writer every passthrough exposes.
+// It is an interface so the optional methods of `*ResponseWriter` (e.g. `Flush`) aren't promoted.
+type passthroughWriter interface {
+	http.ResponseWriter
+	Unwrap() http.ResponseWriter
+}
+
+// Passthrough returns the response writer as an `http.ResponseWriter` that implements exactly the optional interfaces the inner
+// writer does (of `http.Flusher`, `http.Hijacker`, `http.Pusher`, `io.ReaderFrom` and `http.CloseNotifier`),
+// so handlers that check for them (e.g. for server sent events or websockets) behave as they would without it.
+func (rw *ResponseWriter) Passthrough() http.ResponseWriter {
+	var supports int
+	if _, ok := rw.innerResponse.(http.Flusher); ok {
+		supports |= supportsFlusher
+	}
+	if _, ok := rw.innerResponse.(http.Hijacker); ok {
+		supports |= supportsHijacker
+	}
+	if _, ok := rw.innerResponse.(http.Pusher); ok {
+		supports |= supportsPusher
+	}
+	if _, ok := rw.innerResponse.(io.ReaderFrom); ok {
+		supports |= supportsReaderFrom
+	}
+	if _, ok := rw.innerResponse.(http.CloseNotifier); ok {
+		supports |= supportsCloseNotifier
+	}
+
+	switch supports {
+	case supportsFlusher:
+		return struct {
+			passthroughWriter
+			http.Flusher
+		}{rw, responseFlusher{rw}}
+	case supportsHijacker:
+		return struct {
+			passthroughWriter
+			http.Hijacker
+		}{rw, responseHijacker{rw}}
+	case supportsFlusher | supportsHijacker:
+		return struct {
+			passthroughWriter
+			http.Flusher
+			http.Hijacker
+		}{rw, responseFlusher{rw}, responseHijacker{rw}}
+	case supportsPusher:
+		return struct {
+			passthroughWriter
+			http.Pusher
+		}{rw, responsePusher{rw}}
+	case supportsFlusher | supportsPusher:
+		return struct {
+			passthroughWriter
+			http.Flusher
+			http.Pusher
+		}{rw, responseFlusher{rw}, responsePusher{rw}}
+	case supportsHijacker | supportsPusher:
+		return struct {
+			passthroughWriter
+			http.Hijacker
+			http.Pusher
+		}{rw, responseHijacker{rw}, responsePusher{rw}}
+	case supportsFlusher | supportsHijacker | supportsPusher:
+		return struct {
+			passthroughWriter
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+		}{rw, responseFlusher{rw}, responseHijacker{rw}, responsePusher{rw}}
+	case supportsReaderFrom:
+		return struct {
+			passthroughWriter
+			io.ReaderFrom
+		}{rw, responseReaderFrom{rw}}
+	case supportsFlusher | supportsReaderFrom:
+		return struct {
+			passthroughWriter
+			http.Flusher
+			io.ReaderFrom
+		}{rw, responseFlusher{rw}, responseReaderFrom{rw}}
+	case supportsHijacker | supportsReaderFrom:
+		return struct {
+			passthroughWriter
+			http.Hijacker
+			io.ReaderFrom
+		}{rw, responseHijacker{rw}, responseReaderFrom{rw}}
+	case supportsFlusher | supportsHijacker | supportsReaderFrom:
+		return struct {
+			passthroughWriter
+			http.Flusher
+			http.Hijacker
+			io.ReaderFrom
+		}{rw, responseFlusher{rw}, responseHijacker{rw}, responseReaderFrom{rw}}
+	case supportsPusher | supportsReaderFrom:
+		return struct {
+			passthroughWriter
+			http.Pusher
+			io.ReaderFrom
+		}{rw, responsePusher{rw}, responseReaderFrom{rw}}
+	case supportsFlusher | supportsPusher | supportsReaderFrom:
+		return struct {
+			passthroughWriter
+			http.Flusher
+			http.Pusher
+			io.ReaderFrom
+		}{rw, responseFlusher{rw}, responsePusher{rw}, responseReaderFrom{rw}}
+	case supportsHijacker | supportsPusher | supportsReaderFrom:
+		return struct {
+			passthroughWriter
+			http.Hijacker
+			http.Pusher
+			io.ReaderFrom
+		}{rw, responseHijacker{rw}, responsePusher{rw}, responseReaderFrom{rw}}
+	case supportsFlusher | supportsHijacker | supportsPusher | supportsReaderFrom:
+		return struct {
+			passthroughWriter
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+			io.ReaderFrom
+		}{rw, responseFlusher{rw}, responseHijacker{rw}, responsePusher{rw}, responseReaderFrom{rw}}
+	case supportsCloseNotifier:
+		return struct {
+			passthroughWriter
+			http.CloseNotifier
+		}{rw, responseCloseNotifier{rw}}
+	case supportsFlusher | supportsCloseNotifier:
+		return struct {
+			passthroughWriter
+			http.Flusher
+			http.CloseNotifier
+		}{rw, responseFlusher{rw}, responseCloseNotifier{rw}}
+	case supportsHijacker | supportsCloseNotifier:
+		return struct {
+			passthroughWriter
+			http.Hijacker
+			http.CloseNotifier
+		}{rw, responseHijacker{rw}, responseCloseNotifier{rw}}
+	case supportsFlusher | supportsHijacker | supportsCloseNotifier:
+		return struct {
+			passthroughWriter
+			http.Flusher
+			http.Hijacker
+			http.CloseNotifier
+		}{rw, responseFlusher{rw}, responseHijacker{rw}, responseCloseNotifier{rw}}
+	case supportsPusher | supportsCloseNotifier:
+		return struct {
+			passthroughWriter
+			http.Pusher
+			http.CloseNotifier
+		}{rw, responsePusher{rw}, responseCloseNotifier{rw}}
+	case supportsFlusher | supportsPusher | supportsCloseNotifier:
+		return struct {
+			passthroughWriter
+			http.Flusher
+			http.Pusher
+			http.CloseNotifier
+		}{rw, responseFlusher{rw}, responsePusher{rw}, responseCloseNotifier{rw}}
+	case supportsHijacker | supportsPusher | supportsCloseNotifier:
+		return struct {
+			passthroughWriter
+			http.Hijacker
+			http.Pusher
+			http.CloseNotifier
+		}{rw, responseHijacker{rw}, responsePusher{rw}, responseCloseNotifier{rw}}
+	case supportsFlusher | supportsHijacker | supportsPusher | supportsCloseNotifier:
+		return struct {
+			passthroughWriter
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+			http.CloseNotifier
+		}{rw, responseFlusher{rw}, responseHijacker{rw}, responsePusher{rw}, responseCloseNotifier{rw}}
+	case supportsReaderFrom | supportsCloseNotifier:
+		return struct {
+			passthroughWriter
+			io.ReaderFrom
+			http.CloseNotifier
+		}{rw, responseReaderFrom{rw}, responseCloseNotifier{rw}}
+	case supportsFlusher | supportsReaderFrom | supportsCloseNotifier:
+		return struct {
+			passthroughWriter
+			http.Flusher
+			io.ReaderFrom
+			http.CloseNotifier
+		}{rw, responseFlusher{rw}, responseReaderFrom{rw}, responseCloseNotifier{rw}}
+	case supportsHijacker | supportsReaderFrom | supportsCloseNotifier:
+		return struct {
+			passthroughWriter
+			http.Hijacker
+			io.ReaderFrom
+			http.CloseNotifier
+		}{rw, responseHijacker{rw}, responseReaderFrom{rw}, responseCloseNotifier{rw}}
+	case supportsFlusher | supportsHijacker | supportsReaderFrom | supportsCloseNotifier:
+		return struct {
+			passthroughWriter
+			http.Flusher
+			http.Hijacker
+			io.ReaderFrom
+			http.CloseNotifier
+		}{rw, responseFlusher{rw}, responseHijacker{rw}, responseReaderFrom{rw}, responseCloseNotifier{rw}}
+	case supportsPusher | supportsReaderFrom | supportsCloseNotifier:
+		return struct {
+			passthroughWriter
+			http.Pusher
+			io.ReaderFrom
+			http.CloseNotifier
+		}{rw, responsePusher{rw}, responseReaderFrom{rw}, responseCloseNotifier{rw}}
+	case supportsFlusher | supportsPusher | supportsReaderFrom | supportsCloseNotifier:
+		return struct {
+			passthroughWriter
+			http.Flusher
+			http.Pusher
+			io.ReaderFrom
+			http.CloseNotifier
+		}{rw, responseFlusher{rw}, responsePusher{rw}, responseReaderFrom{rw}, responseCloseNotifier{rw}}
+	case supportsHijacker | supportsPusher | supportsReaderFrom | supportsCloseNotifier:
+		return struct {
+			passthroughWriter
+			http.Hijacker
+			http.Pusher
+			io.ReaderFrom
+			http.CloseNotifier
+		}{rw, responseHijacker{rw}, responsePusher{rw}, responseReaderFrom{rw}, responseCloseNotifier{rw}}
+	case supportsFlusher | supportsHijacker | supportsPusher | supportsReaderFrom | supportsCloseNotifier:
+		return struct {
+			passthroughWriter
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+			io.ReaderFrom
+			http.CloseNotifier
+		}{rw, responseFlusher{rw}, responseHijacker{rw}, responsePusher{rw}, responseReaderFrom{rw}, responseCloseNotifier{rw}}
+	}
+	return struct{ passthroughWriter }{rw}
+}
+
+type responseFlusher struct{ rw *ResponseWriter }
+
+func (rf responseFlusher) Flush() { rf.rw.Flush() }
+
+type responseHijacker struct{ rw *ResponseWriter }
+
+func (rh responseHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return rh.rw.innerResponse.(http.Hijacker).Hijack()
+}
+
+type responsePusher struct{ rw *ResponseWriter }
+
+func (rp responsePusher) Push(target string, opts *http.PushOptions) error {
+	return rp.rw.innerResponse.(http.Pusher).Push(target, opts)
+}
+
+type responseReaderFrom struct{ rw *ResponseWriter }
+
+func (rrf responseReaderFrom) ReadFrom(r io.Reader) (int64, error) { return rrf.rw.readFrom(r) }
+
+type responseCloseNotifier struct{ rw *ResponseWriter }
+
+func (rcn responseCloseNotifier) CloseNotify() <-chan bool {
+	return rcn.rw.innerResponse.(http.CloseNotifier).CloseNotify()
+}
@@ -0,0 +1,117 @@
+package logger
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	assert "github.com/blendlabs/go-assert"
+)
+
+// hijackingResponse is an inner writer that implements `http.Hijacker` and `io.ReaderFrom`, but not `http.Flusher`.
+type hijackingResponse struct {
+	http.ResponseWriter
+	recorder *httptest.ResponseRecorder
+	hijacked bool
+	readFrom int64
+}
+
+func newHijackingResponse() *hijackingResponse {
+	recorder := httptest.NewRecorder()
+	return &hijackingResponse{ResponseWriter: recorder, recorder: recorder}
+}
+
+func (hr *hijackingResponse) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hr.hijacked = true
+	return nil, nil, nil
+}
+
+func (hr *hijackingResponse) ReadFrom(r io.Reader) (int64, error) {
+	n, err := io.Copy(hr.recorder.Body, r)
+	hr.readFrom += n
+	return n, err
+}
+
+func TestResponseWriterImplicitStatus(t *testing.T) {
+	assert := assert.New(t)
+
+	rw := NewResponseWriter(httptest.NewRecorder())
+	assert.Zero(rw.StatusCode())
+	assert.Zero(rw.TimeToFirstByte())
+
+	rw.Write([]byte("hello"))
+	assert.Equal(http.StatusOK, rw.StatusCode())
+	assert.Equal(5, rw.ContentLength())
+	assert.NotZero(rw.TimeToFirstByte())
+
+	rw.WriteHeader(http.StatusInternalServerError)
+	assert.Equal(http.StatusOK, rw.StatusCode(), "the status code can't change once written")
+
+	rw = NewResponseWriter(httptest.NewRecorder())
+	rw.WriteHeader(http.StatusContinue)
+	assert.Zero(rw.StatusCode(), "informational status codes shouldn't be recorded")
+	rw.WriteHeader(http.StatusCreated)
+	assert.Equal(http.StatusCreated, rw.StatusCode())
+}
+
+func TestResponseWriterPassthrough(t *testing.T) {
+	assert := assert.New(t)
+
+	recorder := httptest.NewRecorder()
+	rw := NewResponseWriter(recorder)
+	passthrough := rw.Passthrough()
+
+	flusher, isFlusher := passthrough.(http.Flusher)
+	assert.True(isFlusher)
+	_, isHijacker := passthrough.(http.Hijacker)
+	assert.False(isHijacker)
+	_, isReaderFrom := passthrough.(io.ReaderFrom)
+	assert.False(isReaderFrom)
+	_, isPusher := passthrough.(http.Pusher)
+	assert.False(isPusher)
+
+	flusher.Flush()
+	assert.True(recorder.Flushed)
+	assert.Equal(http.StatusOK, rw.StatusCode())
+	assert.Equal(recorder, http.ResponseWriter(passthrough.(interface{ Unwrap() http.ResponseWriter }).Unwrap()))
+
+	inner := newHijackingResponse()
+	rw = NewResponseWriter(inner)
+	passthrough = rw.Passthrough()
+
+	_, isFlusher = passthrough.(http.Flusher)
+	assert.False(isFlusher)
+	hijacker, isHijacker := passthrough.(http.Hijacker)
+	assert.True(isHijacker)
+	readerFrom, isReaderFrom := passthrough.(io.ReaderFrom)
+	assert.True(isReaderFrom)
+
+	hijacker.Hijack()
+	assert.True(inner.hijacked)
+
+	written, err := readerFrom.ReadFrom(strings.NewReader("hello world"))
+	assert.Nil(err)
+	assert.Equal(11, written)
+	assert.Equal(11, inner.readFrom)
+	assert.Equal(11, rw.ContentLength())
+	assert.Equal(http.StatusOK, rw.StatusCode())
+}
+
+func TestResponseWriterPassthroughCapturesReadFrom(t *testing.T) {
+	assert := assert.New(t)
+
+	inner := newHijackingResponse()
+	rw := NewResponseWriter(inner)
+	rw.captureBody(5)
+
+	written, err := rw.Passthrough().(io.ReaderFrom).ReadFrom(strings.NewReader("hello world"))
+	assert.Nil(err)
+	assert.Equal(11, written)
+	assert.Zero(inner.readFrom, "captured responses should be written through Write")
+	assert.Equal("hello", string(rw.capturedBody()))
+	assert.Equal("hello world", inner.recorder.Body.String())
+}
@@ -0,0 +1,85 @@
+package logger
+
+import (
+	"strings"
+	"time"
+
+	exception "github.com/blendlabs/go-exception"
+)
+
+const (
+	// RotationHourly is the rotation interval for hourly files.
+	RotationHourly = time.Hour
+	// RotationDaily is the rotation interval for daily files.
+	RotationDaily = 24 * time.Hour
+)
+
+// NewRotationSchedule returns a new rotation schedule for a given interval in a given time zone.
+// A nil location uses UTC.
+func NewRotationSchedule(interval time.Duration, location *time.Location) *RotationSchedule {
+	if location == nil {
+		location = time.UTC
+	}
+	return &RotationSchedule{
+		Interval: interval,
+		Location: location,
+	}
+}
+
+// ParseRotationSchedule parses a rotation schedule from `hourly`, `daily` or a duration (e.g. `30m`) and an IANA time zone name.
+// An empty time zone name uses UTC.
+func ParseRotationSchedule(schedule, timeZone string) (*RotationSchedule, error) {
+	var interval time.Duration
+	switch strings.ToLower(strings.TrimSpace(schedule)) {
+	case "hourly":
+		interval = RotationHourly
+	case "daily":
+		interval = RotationDaily
+	default:
+		parsed, err := time.ParseDuration(strings.TrimSpace(schedule))
+		if err != nil {
+			return nil, exception.Newf("Invalid rotation schedule `%s`: %v", schedule, err)
+		}
+		interval = parsed
+	}
+	if interval <= 0 {
+		return nil, exception.Newf("Invalid rotation schedule `%s`: interval must be positive", schedule)
+	}
+
+	location := time.UTC
+	if len(timeZone) > 0 {
+		loaded, err := time.LoadLocation(timeZone)
+		if err != nil {
+			return nil, exception.Newf("Invalid rotation time zone `%s`: %v", timeZone, err)
+		}
+		location = loaded
+	}
+	return NewRotationSchedule(interval, location), nil
+}
+
+// RotationSchedule rotates files on wall clock boundaries in a given time zone.
+// Intervals that evenly divide a day are aligned to midnight (e.g. hourly files rotate on the hour),
+// intervals that are whole days rotate at midnight, and any other interval is measured from the last rotation.
+type RotationSchedule struct {
+	Interval time.Duration
+	Location *time.Location
+}
+
+// Next returns the next rotation boundary after a given time.
+func (rs *RotationSchedule) Next(after time.Time) time.Time {
+	location := rs.Location
+	if location == nil {
+		location = time.UTC
+	}
+	local := after.In(location)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+
+	if rs.Interval >= RotationDaily && rs.Interval%RotationDaily == 0 {
+		return midnight.AddDate(0, 0, int(rs.Interval/RotationDaily))
+	}
+	if RotationDaily%rs.Interval == 0 {
+		elapsed := local.Sub(midnight)
+		return midnight.Add((elapsed/rs.Interval + 1) * rs.Interval)
+	}
+	return after.Add(rs.Interval)
+}
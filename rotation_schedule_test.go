@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"testing"
+	"time"
+
+	assert "github.com/blendlabs/go-assert"
+)
+
+func TestParseRotationSchedule(t *testing.T) {
+	assert := assert.New(t)
+
+	hourly, err := ParseRotationSchedule("hourly", "")
+	assert.Nil(err)
+	assert.Equal(RotationHourly, hourly.Interval)
+	assert.Equal(time.UTC, hourly.Location)
+
+	daily, err := ParseRotationSchedule("DAILY", "UTC")
+	assert.Nil(err)
+	assert.Equal(RotationDaily, daily.Interval)
+
+	custom, err := ParseRotationSchedule("15m", "")
+	assert.Nil(err)
+	assert.Equal(15*time.Minute, custom.Interval)
+
+	_, err = ParseRotationSchedule("weekly-ish", "")
+	assert.NotNil(err)
+	_, err = ParseRotationSchedule("-1h", "")
+	assert.NotNil(err)
+	_, err = ParseRotationSchedule("hourly", "Not/AZone")
+	assert.NotNil(err)
+}
+
+func TestRotationScheduleNextHourly(t *testing.T) {
+	assert := assert.New(t)
+
+	schedule := NewRotationSchedule(RotationHourly, nil)
+	assert.Equal(time.Date(2017, 03, 04, 15, 0, 0, 0, time.UTC), schedule.Next(time.Date(2017, 03, 04, 14, 25, 0, 0, time.UTC)))
+	assert.Equal(time.Date(2017, 03, 04, 15, 0, 0, 0, time.UTC), schedule.Next(time.Date(2017, 03, 04, 14, 0, 0, 0, time.UTC)))
+	assert.Equal(time.Date(2017, 03, 05, 0, 0, 0, 0, time.UTC), schedule.Next(time.Date(2017, 03, 04, 23, 59, 59, 0, time.UTC)))
+}
+
+func TestRotationScheduleNextDailyInLocation(t *testing.T) {
+	assert := assert.New(t)
+
+	location := time.FixedZone("UTC-8", -8*60*60)
+	schedule := NewRotationSchedule(RotationDaily, location)
+
+	// 06:00 UTC is 22:00 the previous day in UTC-8, so the next boundary is local midnight (08:00 UTC).
+	next := schedule.Next(time.Date(2017, 03, 04, 6, 0, 0, 0, time.UTC))
+	assert.True(next.Equal(time.Date(2017, 03, 04, 8, 0, 0, 0, time.UTC)), next.String())
+}
+
+func TestRotationScheduleNextAligned(t *testing.T) {
+	assert := assert.New(t)
+
+	schedule := NewRotationSchedule(15*time.Minute, nil)
+	assert.Equal(time.Date(2017, 03, 04, 14, 30, 0, 0, time.UTC), schedule.Next(time.Date(2017, 03, 04, 14, 16, 0, 0, time.UTC)))
+
+	unaligned := NewRotationSchedule(7*time.Minute, nil)
+	assert.Equal(time.Date(2017, 03, 04, 14, 23, 0, 0, time.UTC), unaligned.Next(time.Date(2017, 03, 04, 14, 16, 0, 0, time.UTC)))
+}
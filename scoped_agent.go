@@ -0,0 +1,313 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// scopedAgentKey is the context key a scoped agent is stored under.
+type scopedAgentKey struct{}
+
+// WithContext returns a copy of a context that carries a scoped agent, for `FromContext` to return further down the call chain.
+func WithContext(ctx context.Context, agent *ScopedAgent) context.Context {
+	return context.WithValue(ctx, scopedAgentKey{}, agent)
+}
+
+// FromContext returns the scoped agent carried by a context.
+// If the context doesn't carry one, it returns a scope of the default agent without fields.
+func FromContext(ctx context.Context) *ScopedAgent {
+	if ctx != nil {
+		if agent, ok := ctx.Value(scopedAgentKey{}).(*ScopedAgent); ok && agent != nil {
+			return agent
+		}
+	}
+	return &ScopedAgent{a: Default()}
+}
+
+// WithFields returns a scoped agent that adds the fields to every event it writes.
+func (da *Agent) WithFields(fields Fields) *ScopedAgent {
+	return &ScopedAgent{a: da, fields: fields}
+}
+
+// With returns a scoped agent that adds the given keys and values to every event it writes.
+func (da *Agent) With(keysAndValues ...interface{}) *ScopedAgent {
+	return da.WithFields(NewFields(keysAndValues...))
+}
+
+// Sub returns a child agent for a component of the application (e.g. `billing`).
+// Its events carry a `component` field, which the text formatter shows as a label (`billing`, or `api/billing` when the writer shows its own label).
+func (da *Agent) Sub(component string) *ScopedAgent {
+	return da.WithFields(nil).Sub(component)
+}
+
+// WithContext returns a copy of a context that carries a scope of the agent without fields.
+func (da *Agent) WithContext(ctx context.Context) context.Context {
+	return WithContext(ctx, da.WithFields(nil))
+}
+
+// ScopedAgent is an agent that adds a set of inherited fields (e.g. a request id, user or route) to every event it writes,
+// and to the state its listeners receive. Child agents (see `Sub`) are scoped agents with a component, and can override the events
+// that are enabled.
+// It shares the queue, writer, events and listeners of the agent it wraps; scoping an agent only copies the fields.
+type ScopedAgent struct {
+	a      *Agent
+	fields Fields
+	// events are the event overrides of the scope and its parents, nearest first.
+	events []*EventFlagSet
+}
+
+// Agent returns the underlying agent.
+func (sa *ScopedAgent) Agent() *Agent {
+	if sa == nil {
+		return nil
+	}
+	return sa.a
+}
+
+// Fields returns the fields the scoped agent adds to events.
+func (sa *ScopedAgent) Fields() Fields {
+	if sa == nil {
+		return nil
+	}
+	return sa.fields
+}
+
+// WithFields returns a scoped agent that adds the fields to the ones inherited from this scope.
+// Fields with the same key as an inherited field replace it.
+func (sa *ScopedAgent) WithFields(fields Fields) *ScopedAgent {
+	if sa == nil {
+		return nil
+	}
+	return &ScopedAgent{a: sa.a, fields: sa.fields.Merge(fields), events: sa.events}
+}
+
+// With returns a scoped agent that adds the given keys and values to the fields inherited from this scope.
+func (sa *ScopedAgent) With(keysAndValues ...interface{}) *ScopedAgent {
+	return sa.WithFields(NewFields(keysAndValues...))
+}
+
+// Sub returns a child agent for a component within this scope; its component is appended to the scope's (`billing/invoices`).
+func (sa *ScopedAgent) Sub(component string) *ScopedAgent {
+	if sa == nil {
+		return nil
+	}
+	return sa.WithFields(NewFields(FieldComponent, composeLabel(sa.Component(), component)))
+}
+
+// Component returns the component of a child agent, or an empty string if the scope doesn't have one.
+func (sa *ScopedAgent) Component() string {
+	if sa == nil {
+		return ""
+	}
+	if component, hasComponent := sa.fields.Get(FieldComponent); hasComponent {
+		return fmt.Sprintf("%v", component)
+	}
+	return ""
+}
+
+// WithEvents returns a scoped agent that overrides the events enabled in this scope.
+// Events the set doesn't decide (it has no entry for them, they have no severity at or above its minimum, and it isn't set to `all`
+// or `none`) fall back to this scope's events, and finally to the underlying agent's.
+// The set is copied, so later changes to it aren't seen by the scope.
+func (sa *ScopedAgent) WithEvents(events *EventFlagSet) *ScopedAgent {
+	if sa == nil {
+		return nil
+	}
+	overrides := make([]*EventFlagSet, 0, len(sa.events)+1)
+	overrides = append(overrides, events.clone())
+	return &ScopedAgent{a: sa.a, fields: sa.fields, events: append(overrides, sa.events...)}
+}
+
+// WithContext returns a copy of a context that carries the scoped agent.
+func (sa *ScopedAgent) WithContext(ctx context.Context) context.Context {
+	return WithContext(ctx, sa)
+}
+
+// IsEnabled returns if an event is enabled by the scope's event overrides, falling back to the underlying agent.
+func (sa *ScopedAgent) IsEnabled(eventFlag EventFlag) bool {
+	if sa == nil || sa.a == nil {
+		return false
+	}
+	for _, events := range sa.events {
+		if enabled, decided := events.lookup(eventFlag); decided {
+			return enabled
+		}
+	}
+	return sa.a.IsEnabled(eventFlag)
+}
+
+// OnEvent fires the currently configured event listeners, with the scope's fields after the state.
+func (sa *ScopedAgent) OnEvent(eventFlag EventFlag, state ...interface{}) {
+	if sa.IsEnabled(eventFlag) {
+		sa.a.onEvent(eventFlag, appendFields(state, sa.fields)...)
+	}
+}
+
+// Infof logs an informational message to the output stream.
+func (sa *ScopedAgent) Infof(format string, args ...interface{}) {
+	if sa == nil {
+		return
+	}
+	sa.WriteEventWithFields(EventInfo, ColorLightWhite, nil, format, args...)
+}
+
+// Debugf logs a debug message to the output stream.
+func (sa *ScopedAgent) Debugf(format string, args ...interface{}) {
+	if sa == nil {
+		return
+	}
+	sa.WriteEventWithFields(EventDebug, ColorLightYellow, nil, format, args...)
+}
+
+// InfoWithFields logs an informational message with structured fields to the output stream.
+func (sa *ScopedAgent) InfoWithFields(fields Fields, format string, args ...interface{}) {
+	if sa == nil {
+		return
+	}
+	sa.WriteEventWithFields(EventInfo, ColorLightWhite, fields, format, args...)
+}
+
+// DebugWithFields logs a debug message with structured fields to the output stream.
+func (sa *ScopedAgent) DebugWithFields(fields Fields, format string, args ...interface{}) {
+	if sa == nil {
+		return
+	}
+	sa.WriteEventWithFields(EventDebug, ColorLightYellow, fields, format, args...)
+}
+
+// Warningf logs a debug message to the output stream.
+func (sa *ScopedAgent) Warningf(format string, args ...interface{}) error {
+	if sa == nil {
+		return nil
+	}
+	if sa.IsEnabled(EventWarning) {
+		args = sa.a.captureArgs(args)
+	}
+	return sa.Warning(newLazyError(format, args...))
+}
+
+// Warning logs a warning error to std err.
+func (sa *ScopedAgent) Warning(err error) error {
+	if sa == nil {
+		return err
+	}
+	return sa.ErrorEventWithState(EventWarning, ColorLightYellow, err)
+}
+
+// WarningWithReq logs a warning error to std err with a request, and its request id if it has one.
+func (sa *ScopedAgent) WarningWithReq(err error, req *http.Request) error {
+	if sa == nil {
+		return err
+	}
+	return sa.ErrorEventWithFields(EventWarning, ColorLightYellow, err, requestIDFields(req), req)
+}
+
+// WarningWithFields logs a warning error with structured fields to std err.
+func (sa *ScopedAgent) WarningWithFields(err error, fields Fields) error {
+	if sa == nil {
+		return err
+	}
+	return sa.ErrorEventWithFields(EventWarning, ColorLightYellow, err, fields)
+}
+
+// Errorf writes an event to the log and triggers event listeners.
+func (sa *ScopedAgent) Errorf(format string, args ...interface{}) error {
+	if sa == nil {
+		return nil
+	}
+	if sa.IsEnabled(EventError) {
+		args = sa.a.captureArgs(args)
+	}
+	return sa.Error(newLazyError(format, args...))
+}
+
+// Error logs an error to std err.
+func (sa *ScopedAgent) Error(err error) error {
+	if sa == nil {
+		return err
+	}
+	return sa.ErrorEventWithState(EventError, ColorRed, err)
+}
+
+// ErrorWithReq logs an error to std err with a request, and its request id if it has one.
+func (sa *ScopedAgent) ErrorWithReq(err error, req *http.Request) error {
+	if sa == nil {
+		return err
+	}
+	return sa.ErrorEventWithFields(EventError, ColorRed, err, requestIDFields(req), req)
+}
+
+// ErrorWithFields logs an error with structured fields to std err.
+func (sa *ScopedAgent) ErrorWithFields(err error, fields Fields) error {
+	if sa == nil {
+		return err
+	}
+	return sa.ErrorEventWithFields(EventError, ColorRed, err, fields)
+}
+
+// Fatalf writes an event to the log and triggers event listeners.
+func (sa *ScopedAgent) Fatalf(format string, args ...interface{}) error {
+	if sa == nil {
+		return nil
+	}
+	if sa.IsEnabled(EventFatalError) {
+		args = sa.a.captureArgs(args)
+	}
+	return sa.Fatal(newLazyError(format, args...))
+}
+
+// Fatal logs the result of a panic to std err.
+func (sa *ScopedAgent) Fatal(err error) error {
+	if sa == nil {
+		return err
+	}
+	return sa.ErrorEventWithState(EventFatalError, ColorRed, err)
+}
+
+// FatalWithReq logs the result of a fatal error to std err with a request, and its request id if it has one.
+func (sa *ScopedAgent) FatalWithReq(err error, req *http.Request) error {
+	if sa == nil {
+		return err
+	}
+	return sa.ErrorEventWithFields(EventFatalError, ColorRed, err, requestIDFields(req), req)
+}
+
+// FatalWithFields logs the result of a fatal error with structured fields to std err.
+func (sa *ScopedAgent) FatalWithFields(err error, fields Fields) error {
+	if sa == nil {
+		return err
+	}
+	return sa.ErrorEventWithFields(EventFatalError, ColorRed, err, fields)
+}
+
+// WriteEventf writes to the standard output and triggers events.
+func (sa *ScopedAgent) WriteEventf(event EventFlag, color AnsiColorCode, format string, args ...interface{}) {
+	if sa == nil {
+		return
+	}
+	sa.WriteEventWithFields(event, color, nil, format, args...)
+}
+
+// WriteEventWithFields writes to the standard output with the scope's fields, followed by the given fields, and triggers events.
+func (sa *ScopedAgent) WriteEventWithFields(event EventFlag, color AnsiColorCode, fields Fields, format string, args ...interface{}) {
+	if sa.IsEnabled(event) {
+		sa.a.writeEventWithFields(event, color, sa.fields.Merge(fields), format, args...)
+	}
+}
+
+// ErrorEventWithState writes an error and triggers events with a given state.
+func (sa *ScopedAgent) ErrorEventWithState(event EventFlag, color AnsiColorCode, err error, state ...interface{}) error {
+	if sa == nil {
+		return err
+	}
+	return sa.ErrorEventWithFields(event, color, err, nil, state...)
+}
+
+// ErrorEventWithFields writes an error with the scope's fields, followed by the given fields, and triggers events with a given state.
+func (sa *ScopedAgent) ErrorEventWithFields(event EventFlag, color AnsiColorCode, err error, fields Fields, state ...interface{}) error {
+	if err != nil && sa.IsEnabled(event) {
+		sa.a.errorEventWithFields(event, color, err, sa.fields.Merge(fields), state...)
+	}
+	return err
+}
@@ -0,0 +1,185 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	assert "github.com/blendlabs/go-assert"
+)
+
+func TestScopedAgentWritesFields(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := bytes.NewBuffer(nil)
+	writer := NewWriter(buffer)
+	writer.SetShowTimestamp(false)
+	writer.SetUseAnsiColors(false)
+	da := NewWithWriter(NewEventFlagSet(EventInfo, EventError), writer)
+	defer da.Close()
+
+	scoped := da.With("request_id", "abc123", "route", "/users")
+	assert.Equal(da, scoped.Agent())
+	scoped.Infof("hello %s", "world")
+	scoped.With("route", "/users/:id").InfoWithFields(NewFields("user", "bailey"), "with fields")
+	scoped.Error(fmt.Errorf("this is an error"))
+	assert.Nil(da.Flush(context.Background()))
+
+	output := buffer.String()
+	assert.Contains(output, "[info] hello world request_id=abc123 route=/users")
+	assert.Contains(output, "[info] with fields request_id=abc123 route=/users/:id user=bailey")
+	assert.Contains(output, "[error] this is an error request_id=abc123 route=/users")
+	assert.Equal(NewFields("request_id", "abc123", "route", "/users"), scoped.Fields(), "scoping again shouldn't change the parent")
+}
+
+func TestScopedAgentListenerState(t *testing.T) {
+	assert := assert.New(t)
+
+	da := NewWithWriter(NewEventFlagSet(EventInfo, EventWebRequest), NewWriter(bytes.NewBuffer(nil)))
+	defer da.Close()
+
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	var infoState, requestState []interface{}
+	da.AddEventListener(EventInfo, func(wr *Writer, ts TimeSource, eventFlag EventFlag, state ...interface{}) {
+		defer wg.Done()
+		infoState = state
+	})
+	da.AddEventListener(EventWebRequest, func(wr *Writer, ts TimeSource, eventFlag EventFlag, state ...interface{}) {
+		defer wg.Done()
+		requestState = state
+	})
+
+	scoped := da.With("request_id", "abc123")
+	scoped.Infof("hello")
+	scoped.OnEvent(EventWebRequest, "state")
+	wg.Wait()
+
+	assert.Len(infoState, 2)
+	assert.Equal("hello", infoState[0])
+	assert.Equal(scoped.Fields(), FieldsFromState(infoState...))
+	assert.Len(requestState, 2)
+	assert.Equal("state", requestState[0])
+	assert.Equal(scoped.Fields(), FieldsFromState(requestState...))
+}
+
+func TestScopedAgentContext(t *testing.T) {
+	assert := assert.New(t)
+
+	da := None()
+	defer da.Close()
+
+	scoped := da.With("user", "bailey")
+	ctx := scoped.WithContext(context.Background())
+	assert.Equal(scoped, FromContext(ctx))
+
+	ctx = FromContext(ctx).With("route", "/users").WithContext(ctx)
+	assert.Equal(NewFields("user", "bailey", "route", "/users"), FromContext(ctx).Fields())
+
+	ctx = da.WithContext(context.Background())
+	assert.Equal(da, FromContext(ctx).Agent())
+	assert.Empty(FromContext(ctx).Fields())
+
+	assert.Equal(Default(), FromContext(context.Background()).Agent())
+}
+
+func TestScopedAgentNil(t *testing.T) {
+	assert := assert.New(t)
+
+	var scoped *ScopedAgent
+	scoped.Infof("hello")
+	scoped.OnEvent(EventInfo)
+	assert.Nil(scoped.Agent())
+	assert.Nil(scoped.With("key", "value"))
+	assert.Nil(scoped.Errorf("error"))
+
+	err := fmt.Errorf("error")
+	assert.Equal(err, (&ScopedAgent{}).Error(err))
+	(&ScopedAgent{}).Warningf("warning")
+}
+
+func TestAgentSub(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := bytes.NewBuffer(nil)
+	writer := NewWriter(buffer)
+	writer.SetShowTimestamp(false)
+	writer.SetUseAnsiColors(false)
+	writer.SetShowLabel(true)
+	writer.SetLabel("api")
+	da := NewWithWriter(NewEventFlagSet(EventInfo), writer)
+	defer da.Close()
+
+	billing := da.Sub("billing")
+	assert.Equal("billing", billing.Component())
+	invoices := billing.With("invoice", 42).Sub("invoices")
+	assert.Equal("billing/invoices", invoices.Component())
+
+	billing.Infof("charged")
+	invoices.Infof("sent")
+	da.Infof("plain")
+	assert.Nil(da.Flush(context.Background()))
+
+	output := buffer.String()
+	assert.Contains(output, "api/billing [info] charged\n")
+	assert.Contains(output, "api/billing/invoices [info] sent invoice=42\n")
+	assert.Contains(output, "api [info] plain\n")
+
+	// the component is shown without the writer's label, too.
+	buffer.Reset()
+	writer.SetShowLabel(false)
+	invoices.Infof("sent")
+	da.Infof("plain")
+	assert.Nil(da.Flush(context.Background()))
+	output = buffer.String()
+	assert.Contains(output, "billing/invoices [info] sent invoice=42\n")
+	assert.Contains(output, "[info] plain\n")
+	assert.NotContains(output, "api")
+
+	buffer.Reset()
+	writer.SetFormatter(&JSONFormatter{})
+	billing.Infof("charged")
+	assert.Nil(da.Flush(context.Background()))
+	assert.Equal(`{"event":"info","label":"api","message":"charged","component":"billing"}`+"\n", buffer.String())
+}
+
+func TestScopedAgentWithEvents(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := bytes.NewBuffer(nil)
+	writer := NewWriter(buffer)
+	writer.SetShowTimestamp(false)
+	writer.SetUseAnsiColors(false)
+	da := NewWithWriter(NewEventFlagSet(EventInfo, EventError), writer)
+	defer da.Close()
+
+	billing := da.Sub("billing").WithEvents(NewEventFlagSetFromCSV("debug,-info"))
+	assert.True(billing.IsEnabled(EventDebug))
+	assert.False(billing.IsEnabled(EventInfo))
+	assert.True(billing.IsEnabled(EventError), "events the override doesn't decide should fall back to the agent")
+	assert.False(da.IsEnabled(EventDebug))
+
+	invoices := billing.Sub("invoices").WithEvents(NewEventFlagSet(EventInfo))
+	assert.True(invoices.IsEnabled(EventInfo))
+	assert.True(invoices.IsEnabled(EventDebug), "events the override doesn't decide should fall back to the parent scope")
+	assert.False(billing.IsEnabled(EventInfo))
+
+	quiet := da.Sub("quiet").WithEvents(NewEventFlagSetNone())
+	assert.False(quiet.IsEnabled(EventError))
+
+	billing.Debugf("debug from billing")
+	billing.Infof("info from billing")
+	billing.Errorf("error from billing")
+	quiet.Errorf("error from quiet")
+	da.Debugf("debug from agent")
+	assert.Nil(da.Flush(context.Background()))
+
+	output := buffer.String()
+	assert.Contains(output, "billing [debug] debug from billing\n")
+	assert.Contains(output, "billing [error] error from billing\n")
+	assert.NotContains(output, "info from billing")
+	assert.NotContains(output, "error from quiet")
+	assert.NotContains(output, "debug from agent")
+}
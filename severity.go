@@ -0,0 +1,114 @@
+package logger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	exception "github.com/blendlabs/go-exception"
+)
+
+// Severity is how severe an event is, so an `EventFlagSet` can enable every event at or above a level.
+// Severities are ordered by value; the named levels are spaced out so custom levels can sit between them.
+type Severity int
+
+const (
+	// SeverityNone is the severity of events that haven't registered one.
+	SeverityNone Severity = 0
+	// SeverityDebug is the severity of debug messages.
+	SeverityDebug Severity = 10
+	// SeverityInfo is the severity of informational messages.
+	SeverityInfo Severity = 20
+	// SeverityWarning is the severity of warnings.
+	SeverityWarning Severity = 30
+	// SeverityError is the severity of errors.
+	SeverityError Severity = 40
+	// SeverityFatal is the severity of fatal errors.
+	SeverityFatal Severity = 50
+)
+
+var (
+	severityNames = map[Severity]string{
+		SeverityDebug:   "debug",
+		SeverityInfo:    "info",
+		SeverityWarning: "warning",
+		SeverityError:   "error",
+		SeverityFatal:   "fatal",
+	}
+
+	// eventSeverities holds an immutable map[EventFlag]Severity, so reading it doesn't take a lock.
+	// Registrations serialize on the lock and swap in a copy.
+	eventSeveritiesLock sync.Mutex
+	eventSeverities     atomic.Value
+)
+
+func init() {
+	eventSeverities.Store(map[EventFlag]Severity{
+		EventSilly:      SeverityDebug,
+		EventDebug:      SeverityDebug,
+		EventInfo:       SeverityInfo,
+		EventWarning:    SeverityWarning,
+		EventError:      SeverityError,
+		EventFatalError: SeverityFatal,
+	})
+}
+
+// ParseSeverity returns the severity for a level name (`debug`, `info`, `warning`, `error` or `fatal`) or number.
+func ParseSeverity(value string) (Severity, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "warn" {
+		return SeverityWarning, nil
+	}
+	for severity, name := range severityNames {
+		if name == value {
+			return severity, nil
+		}
+	}
+	if number, err := strconv.Atoi(value); err == nil {
+		return Severity(number), nil
+	}
+	return SeverityNone, exception.Newf("Invalid severity `%s`", value)
+}
+
+// String returns the level name of the severity, or its number for custom levels.
+func (s Severity) String() string {
+	if name, hasName := severityNames[s]; hasName {
+		return name
+	}
+	return fmt.Sprintf("%d", int(s))
+}
+
+// RegisterEventSeverity sets the severity of an event flag.
+// Events below the flag in the hierarchy (e.g. `web.request` for `web`) have the same severity unless they register their own.
+func RegisterEventSeverity(eventFlag EventFlag, severity Severity) {
+	eventSeveritiesLock.Lock()
+	defer eventSeveritiesLock.Unlock()
+
+	current := eventSeverities.Load().(map[EventFlag]Severity)
+	registered := make(map[EventFlag]Severity, len(current)+1)
+	for flag, flagSeverity := range current {
+		registered[flag] = flagSeverity
+	}
+	if severity == SeverityNone {
+		delete(registered, eventFlag)
+	} else {
+		registered[eventFlag] = severity
+	}
+	eventSeverities.Store(registered)
+}
+
+// EventSeverity returns the severity of an event flag, and if it has one.
+func EventSeverity(eventFlag EventFlag) (Severity, bool) {
+	registered := eventSeverities.Load().(map[EventFlag]Severity)
+	if severity, hasSeverity := registered[eventFlag]; hasSeverity {
+		return severity, true
+	}
+	for ancestor, hasParent := eventFlag.Parent(); hasParent; ancestor, hasParent = ancestor.Parent() {
+		if severity, hasSeverity := registered[ancestor]; hasSeverity {
+			return severity, true
+		}
+	}
+	return SeverityNone, false
+}
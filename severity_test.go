@@ -0,0 +1,102 @@
+package logger
+
+import (
+	"os"
+	"testing"
+
+	assert "github.com/blendlabs/go-assert"
+)
+
+func TestParseSeverity(t *testing.T) {
+	assert := assert.New(t)
+
+	severity, err := ParseSeverity("WARNING")
+	assert.Nil(err)
+	assert.Equal(SeverityWarning, severity)
+
+	severity, err = ParseSeverity("warn")
+	assert.Nil(err)
+	assert.Equal(SeverityWarning, severity)
+
+	severity, err = ParseSeverity("35")
+	assert.Nil(err)
+	assert.Equal(Severity(35), severity)
+	assert.Equal("35", severity.String())
+	assert.Equal("error", SeverityError.String())
+
+	_, err = ParseSeverity("loud")
+	assert.NotNil(err)
+}
+
+func TestEventSeverity(t *testing.T) {
+	assert := assert.New(t)
+
+	severity, hasSeverity := EventSeverity(EventError)
+	assert.True(hasSeverity)
+	assert.Equal(SeverityError, severity)
+
+	_, hasSeverity = EventSeverity(EventWebRequest)
+	assert.False(hasSeverity)
+
+	RegisterEventSeverity("audit", SeverityWarning+1)
+	defer RegisterEventSeverity("audit", SeverityNone)
+
+	severity, hasSeverity = EventSeverity("audit.login")
+	assert.True(hasSeverity)
+	assert.Equal(SeverityWarning+1, severity)
+}
+
+func TestEventFlagSetMinSeverity(t *testing.T) {
+	assert := assert.New(t)
+
+	set := NewEventFlagSet(EventWebRequest)
+	set.SetMinSeverity(SeverityWarning)
+	assert.Equal(SeverityWarning, set.MinSeverity())
+
+	assert.True(set.IsEnabled(EventWebRequest))
+	assert.True(set.IsEnabled(EventWarning))
+	assert.True(set.IsEnabled(EventError))
+	assert.True(set.IsEnabled(EventFatalError))
+	assert.False(set.IsEnabled(EventInfo))
+	assert.False(set.IsEnabled(EventDebug))
+	assert.False(set.IsEnabled(EventWebResponse))
+
+	set.Enable(EventDebug)
+	set.Disable(EventError)
+	assert.True(set.IsEnabled(EventDebug))
+	assert.False(set.IsEnabled(EventError))
+
+	set.SetMinSeverity(SeverityNone)
+	assert.False(set.IsEnabled(EventWarning))
+}
+
+func TestEventFlagSetMinSeverityWithAll(t *testing.T) {
+	assert := assert.New(t)
+
+	set := NewEventFlagSetFromCSV("all,debug")
+	set.SetMinSeverity(SeverityError)
+
+	assert.True(set.IsEnabled(EventWebRequest))
+	assert.True(set.IsEnabled(EventError))
+	assert.True(set.IsEnabled(EventDebug))
+	assert.False(set.IsEnabled(EventInfo))
+}
+
+func TestEventFlagSetFromEnvironmentLevel(t *testing.T) {
+	assert := assert.New(t)
+
+	oldLogEvents := os.Getenv(EnvironmentVariableLogEvents)
+	oldLogLevel := os.Getenv(EnvironmentVariableLogLevel)
+	defer func() {
+		os.Setenv(EnvironmentVariableLogEvents, oldLogEvents)
+		os.Setenv(EnvironmentVariableLogLevel, oldLogLevel)
+	}()
+	os.Setenv(EnvironmentVariableLogEvents, "web.request,-warning")
+	os.Setenv(EnvironmentVariableLogLevel, "warning")
+
+	set := NewEventFlagSetFromEnvironment()
+	assert.True(set.IsEnabled(EventWebRequest))
+	assert.True(set.IsEnabled(EventError))
+	assert.False(set.IsEnabled(EventWarning))
+	assert.False(set.IsEnabled(EventInfo))
+}
@@ -0,0 +1,523 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	exception "github.com/blendlabs/go-exception"
+)
+
+const (
+	// QueueOverflowSpill appends writes that don't fit in the queue to a spillover file, and replays them once the queue drains.
+	// Listener triggers that don't fit are dropped. It is set with `Agent.EnableSpillover(...)`.
+	QueueOverflowSpill QueueOverflowPolicy = "spill"
+
+	// spillRecordHeaderSize is the size of a record's length and checksum.
+	spillRecordHeaderSize = 8
+	// maxSpillRecordSize is the largest record payload that is written or read; a larger length is read as a corrupt header.
+	maxSpillRecordSize = 4 << 20
+	// spillCorruptSuffix is appended to the path of a spillover file to name the file unreadable records are moved to.
+	spillCorruptSuffix = ".corrupt"
+	// spillReplaySuffix is appended to the path of a spillover file to name the segment records are replayed from.
+	spillReplaySuffix = ".replay"
+)
+
+// SpillCorruptionError is returned when records in a spillover file can't be read (e.g. they were torn by a crash).
+// The unreadable bytes are moved to `Path` rather than dropped.
+type SpillCorruptionError struct {
+	// Lost is how many records couldn't be read. Bytes that can't be split into records count as one.
+	Lost int
+	// Path is the file the unreadable bytes were appended to.
+	Path string
+}
+
+// Error implements error.
+func (sce *SpillCorruptionError) Error() string {
+	return fmt.Sprintf("%d spillover record(s) couldn't be read and were moved to `%s`", sce.Lost, sce.Path)
+}
+
+// spillKind is the kind of write a queued action is, if it is one.
+type spillKind int
+
+const (
+	spillKindNone spillKind = iota
+	spillKindOutput
+	spillKindErrorOutput
+	spillKindError
+)
+
+// spillRecord is a write that has been spilled to disk.
+type spillRecord struct {
+	Time        int64              `json:"time"`
+	Flag        EventFlag          `json:"flag"`
+	Color       AnsiColorCode      `json:"color,omitempty"`
+	ErrorOutput bool               `json:"error_output,omitempty"`
+	Message     string             `json:"message,omitempty"`
+	Err         *spillError        `json:"err,omitempty"`
+	Fields      []spillRecordField `json:"fields,omitempty"`
+}
+
+type spillRecordField struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+// spillError is an error restored from a spill record.
+// It keeps both the error message and the verbose (`%+v`) form, which may include a stack.
+type spillError struct {
+	Message string `json:"message"`
+	Verbose string `json:"verbose,omitempty"`
+}
+
+// Error implements error.
+func (se *spillError) Error() string {
+	return se.Message
+}
+
+// Format implements fmt.Formatter, writing the verbose form for `%+v`.
+func (se *spillError) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') && len(se.Verbose) > 0 {
+		io.WriteString(s, se.Verbose)
+		return
+	}
+	io.WriteString(s, se.Message)
+}
+
+// newSpillRecord returns a spill record for the state of a queued write.
+// Messages are formatted, and field values that can't be serialized as json are written as strings.
+func newSpillRecord(kind spillKind, state ...interface{}) (*spillRecord, error) {
+	if len(state) < 5 {
+		return nil, exception.New("Invalid write state")
+	}
+
+	timeSource, err := stateAsTimeSource(state[0])
+	if err != nil {
+		return nil, err
+	}
+	eventFlag, err := stateAsEventFlag(state[1])
+	if err != nil {
+		return nil, err
+	}
+	color, err := stateAsAnsiColorCode(state[2])
+	if err != nil {
+		return nil, err
+	}
+	fields, err := stateAsFields(state[3])
+	if err != nil {
+		return nil, err
+	}
+
+	record := &spillRecord{
+		Time:        timeSource.UTCNow().UnixNano(),
+		Flag:        eventFlag,
+		Color:       color,
+		ErrorOutput: kind != spillKindOutput,
+	}
+	for _, field := range fields {
+		value := field.Value
+		if _, err := json.Marshal(value); err != nil {
+			value = fmt.Sprintf("%v", value)
+		}
+		record.Fields = append(record.Fields, spillRecordField{Key: field.Key, Value: value})
+	}
+
+	if kind == spillKindError {
+		eventErr, err := stateAsError(state[4])
+		if err != nil {
+			return nil, err
+		}
+		record.Err = &spillError{Message: eventErr.Error(), Verbose: fmt.Sprintf("%+v", eventErr)}
+		return record, nil
+	}
+
+	format, err := stateAsString(state[4])
+	if err != nil {
+		return nil, err
+	}
+	record.Message = fmt.Sprintf(format, state[5:]...)
+	return record, nil
+}
+
+// timeSource returns the time the record was originally queued at.
+func (sr *spillRecord) timeSource() TimeSource {
+	return TimeInstance(time.Unix(0, sr.Time))
+}
+
+// fieldValues returns the record's fields.
+func (sr *spillRecord) fieldValues() Fields {
+	if len(sr.Fields) == 0 {
+		return nil
+	}
+	fields := make(Fields, len(sr.Fields))
+	for x, field := range sr.Fields {
+		fields[x] = Field{Key: field.Key, Value: field.Value}
+	}
+	return fields
+}
+
+// writeTo writes the record to a writer, with its original timestamp.
+func (sr *spillRecord) writeTo(wr *Writer) (err error) {
+	switch {
+	case sr.Err != nil:
+		_, err = wr.WriteErrorWithTimeSource(sr.timeSource(), sr.Flag, sr.Color, sr.Err, sr.fieldValues())
+	case sr.ErrorOutput:
+		_, err = wr.ErrorEventfWithTimeSource(sr.timeSource(), sr.Flag, sr.Color, sr.fieldValues(), "%s", sr.Message)
+	default:
+		_, err = wr.PrintEventfWithTimeSource(sr.timeSource(), sr.Flag, sr.Color, sr.fieldValues(), "%s", sr.Message)
+	}
+	return
+}
+
+// openSpillFile opens (or creates) a spillover file.
+// A replay segment left by a replay that didn't finish is replayed before the file's records.
+func openSpillFile(filePath string) (*spillFile, error) {
+	file, err := openSpillSegment(filePath)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, exception.Wrap(err)
+	}
+	sf := &spillFile{
+		filePath:   filePath,
+		file:       file,
+		hasRecords: stat.Size() > 0,
+	}
+	if replayStat, err := os.Stat(sf.replayPath()); err == nil {
+		sf.hasReplaySegment = replayStat.Size() > 0
+		if !sf.hasReplaySegment {
+			os.Remove(sf.replayPath())
+		}
+	}
+	return sf, nil
+}
+
+// openSpillSegment opens (or creates) a file records are appended to.
+func openSpillSegment(filePath string) (*os.File, error) {
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0666)
+	return file, exception.Wrap(err)
+}
+
+// spillFile is an append only file of spill records.
+// Each record is a big endian uint32 length and crc32 checksum of a json payload, followed by the payload.
+//
+// Records are replayed from a separate segment (`<path>.replay`): a replay renames the file to the segment and starts a new one,
+// so writes can keep spilling while the segment's records are written out.
+type spillFile struct {
+	filePath   string
+	syncRoot   sync.Mutex
+	file       *os.File
+	hasRecords bool
+	// hasReplaySegment is set while there is a replay segment that hasn't been fully replayed.
+	hasReplaySegment bool
+
+	// replayLock serializes replays; it is never held by appends.
+	replayLock sync.Mutex
+}
+
+// Append appends a record to the file.
+func (sf *spillFile) Append(record *spillRecord) error {
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return exception.Wrap(err)
+	}
+	if len(payload) > maxSpillRecordSize {
+		return exception.Newf("Spill record is too large (%d bytes)", len(payload))
+	}
+
+	buffer := bytes.NewBuffer(make([]byte, 0, spillRecordHeaderSize+len(payload)))
+	binary.Write(buffer, binary.BigEndian, uint32(len(payload)))
+	binary.Write(buffer, binary.BigEndian, crc32.ChecksumIEEE(payload))
+	buffer.Write(payload)
+
+	sf.syncRoot.Lock()
+	defer sf.syncRoot.Unlock()
+	if sf.file == nil {
+		return exception.New("Spillover file is closed")
+	}
+	_, err = sf.file.Write(buffer.Bytes())
+	if err != nil {
+		return exception.Wrap(err)
+	}
+	sf.hasRecords = true
+	return nil
+}
+
+// HasRecords returns if there are records waiting to be replayed.
+func (sf *spillFile) HasRecords() bool {
+	sf.syncRoot.Lock()
+	defer sf.syncRoot.Unlock()
+	return sf.hasRecords || sf.hasReplaySegment
+}
+
+// Replay hands each record to a handler, oldest first, and removes the records it replayed.
+// The file's records are moved to a replay segment first, and read from it one at a time, so appends aren't held up by the handler.
+// A replay segment left over by an earlier replay is finished first; records appended during a replay are left for the next one.
+//
+// If the handler fails, replay stops and the failed record and those after it are kept for the next replay.
+// Records that can't be read (torn by a crash, or corrupt) are moved to `<path>.corrupt`, and reported with a `*SpillCorruptionError`.
+// Records replayed from a segment that is interrupted by a crash are replayed again on the next start.
+func (sf *spillFile) Replay(handler func(*spillRecord) error) (replayed int, err error) {
+	sf.replayLock.Lock()
+	defer sf.replayLock.Unlock()
+
+	var lost int
+	// at most a left over segment, then the records in the file when the replay started.
+	for segments := 0; segments < 2; segments++ {
+		hasSegment, segmentErr := sf.nextReplaySegment()
+		if segmentErr != nil {
+			return replayed, segmentErr
+		}
+		if !hasSegment {
+			break
+		}
+		segmentReplayed, segmentLost, segmentErr := sf.replaySegment(handler)
+		replayed += segmentReplayed
+		lost += segmentLost
+		if segmentErr != nil {
+			return replayed, segmentErr
+		}
+	}
+	if lost > 0 {
+		return replayed, &SpillCorruptionError{Lost: lost, Path: sf.corruptPath()}
+	}
+	return replayed, nil
+}
+
+// replayPath returns the path of the segment records are replayed from.
+func (sf *spillFile) replayPath() string {
+	return sf.filePath + spillReplaySuffix
+}
+
+// corruptPath returns the path of the file unreadable records are moved to.
+func (sf *spillFile) corruptPath() string {
+	return sf.filePath + spillCorruptSuffix
+}
+
+// nextReplaySegment returns if there is a replay segment, moving the file's records to a new one if there isn't.
+func (sf *spillFile) nextReplaySegment() (bool, error) {
+	sf.syncRoot.Lock()
+	defer sf.syncRoot.Unlock()
+	if sf.file == nil {
+		return false, nil
+	}
+	if sf.hasReplaySegment {
+		return true, nil
+	}
+	if !sf.hasRecords {
+		return false, nil
+	}
+
+	if err := sf.file.Sync(); err != nil {
+		return false, exception.Wrap(err)
+	}
+	if err := sf.file.Close(); err != nil {
+		return false, exception.Wrap(err)
+	}
+	renameErr := os.Rename(sf.filePath, sf.replayPath())
+	file, err := openSpillSegment(sf.filePath)
+	if err != nil {
+		sf.file = nil
+		return false, err
+	}
+	sf.file = file
+	if renameErr != nil {
+		return false, exception.Wrap(renameErr)
+	}
+	sf.hasRecords = false
+	sf.hasReplaySegment = true
+	return true, nil
+}
+
+// replaySegment hands the records in the replay segment to a handler, then removes the segment.
+// If the handler fails, or unreadable records can't be saved, the segment is cut down to the records from the failure on.
+func (sf *spillFile) replaySegment(handler func(*spillRecord) error) (replayed, lost int, err error) {
+	segment, err := os.Open(sf.replayPath())
+	if err != nil {
+		return 0, 0, exception.Wrap(err)
+	}
+
+	var corrupt *os.File
+	keepFrom, firstCorrupt := int64(-1), int64(-1)
+	reader := newSpillReader(bufio.NewReader(segment))
+	for entry, hasEntry := reader.Next(); hasEntry; entry, hasEntry = reader.Next() {
+		if entry.record == nil {
+			if corrupt == nil {
+				if corrupt, err = os.OpenFile(sf.corruptPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666); err != nil {
+					err = exception.Wrap(err)
+					keepFrom = entry.start
+					break
+				}
+			}
+			if _, err = io.Copy(corrupt, io.NewSectionReader(segment, entry.start, entry.end-entry.start)); err != nil {
+				err = exception.Wrap(err)
+				keepFrom = entry.start
+				break
+			}
+			if firstCorrupt < 0 {
+				firstCorrupt = entry.start
+			}
+			lost++
+			continue
+		}
+		if err = handler(entry.record); err != nil {
+			keepFrom = entry.start
+			break
+		}
+		replayed++
+	}
+	if corrupt != nil {
+		syncErr := corrupt.Sync()
+		if closeErr := corrupt.Close(); syncErr == nil {
+			syncErr = closeErr
+		}
+		if syncErr != nil && err == nil {
+			// the unreadable bytes aren't safely saved, so keep them (and replay the records after them again).
+			err = exception.Wrap(syncErr)
+			keepFrom = firstCorrupt
+		}
+	}
+	segment.Close()
+
+	if keepFrom >= 0 {
+		if keepFrom > 0 {
+			if keepErr := truncateSpillSegmentFront(sf.replayPath(), keepFrom); keepErr != nil {
+				return replayed, lost, keepErr
+			}
+		}
+		return replayed, lost, err
+	}
+
+	if err = os.Remove(sf.replayPath()); err != nil {
+		return replayed, lost, exception.Wrap(err)
+	}
+	sf.syncRoot.Lock()
+	sf.hasReplaySegment = false
+	sf.syncRoot.Unlock()
+	return replayed, lost, nil
+}
+
+// truncateSpillSegmentFront replaces a segment with its bytes from an offset on, by writing them to a temp file and renaming it into place.
+func truncateSpillSegmentFront(filePath string, offset int64) error {
+	segment, err := os.Open(filePath)
+	if err != nil {
+		return exception.Wrap(err)
+	}
+	defer segment.Close()
+	stat, err := segment.Stat()
+	if err != nil {
+		return exception.Wrap(err)
+	}
+
+	tempPath := filePath + ".tmp"
+	tempFile, err := os.OpenFile(tempPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+	if err != nil {
+		return exception.Wrap(err)
+	}
+	_, err = io.Copy(tempFile, io.NewSectionReader(segment, offset, stat.Size()-offset))
+	if err == nil {
+		err = tempFile.Sync()
+	}
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tempPath, filePath)
+	}
+	if err != nil {
+		os.Remove(tempPath)
+		return exception.Wrap(err)
+	}
+	return nil
+}
+
+// Close syncs and closes the file. A replay segment that hasn't been fully replayed is kept for the next start.
+func (sf *spillFile) Close() error {
+	sf.syncRoot.Lock()
+	defer sf.syncRoot.Unlock()
+	if sf.file == nil {
+		return nil
+	}
+	err := sf.file.Sync()
+	closeErr := sf.file.Close()
+	sf.file = nil
+	if err != nil {
+		return exception.Wrap(err)
+	}
+	return exception.Wrap(closeErr)
+}
+
+// spillEntry is a record read from a spillover file, or a run of bytes that couldn't be read as one (with a nil record).
+type spillEntry struct {
+	record *spillRecord
+	start  int64
+	end    int64
+}
+
+// newSpillReader returns a reader for the records in a spillover file.
+func newSpillReader(r io.Reader) *spillReader {
+	return &spillReader{r: r, header: make([]byte, spillRecordHeaderSize)}
+}
+
+// spillReader reads the records in a spillover file one at a time, along with the runs of bytes that couldn't be read as records.
+// A record that fails its checksum is skipped over by its length; a length that is too large, or a record that is cut short,
+// leaves the rest of the file unreadable.
+type spillReader struct {
+	r      io.Reader
+	header []byte
+	offset int64
+	done   bool
+}
+
+// Next returns the next entry, and false once the file is read.
+func (sr *spillReader) Next() (spillEntry, bool) {
+	if sr.done {
+		return spillEntry{}, false
+	}
+	start := sr.offset
+	read, err := io.ReadFull(sr.r, sr.header)
+	if err == io.EOF {
+		sr.done = true
+		return spillEntry{}, false
+	}
+	if err != nil {
+		// a partial header is a torn write at the end of the file.
+		sr.done = true
+		return spillEntry{start: start, end: start + int64(read)}, true
+	}
+	length := binary.BigEndian.Uint32(sr.header[:4])
+	checksum := binary.BigEndian.Uint32(sr.header[4:])
+
+	if length > maxSpillRecordSize {
+		rest, _ := io.Copy(ioutil.Discard, sr.r)
+		sr.done = true
+		return spillEntry{start: start, end: start + spillRecordHeaderSize + rest}, true
+	}
+
+	payload := make([]byte, length)
+	read, err = io.ReadFull(sr.r, payload)
+	sr.offset = start + spillRecordHeaderSize + int64(read)
+	entry := spillEntry{start: start, end: sr.offset}
+	if err != nil {
+		sr.done = true
+		return entry, true
+	}
+	if crc32.ChecksumIEEE(payload) == checksum {
+		var record spillRecord
+		if json.Unmarshal(payload, &record) == nil {
+			entry.record = &record
+		}
+	}
+	return entry, true
+}
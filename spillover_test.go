@@ -0,0 +1,372 @@
+package logger
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	assert "github.com/blendlabs/go-assert"
+	exception "github.com/blendlabs/go-exception"
+)
+
+func TestSpillFileReplay(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+	spillPath := filepath.Join(tempDir, "spill")
+
+	spill, err := openSpillFile(spillPath)
+	assert.Nil(err)
+	assert.False(spill.HasRecords())
+
+	ts := time.Date(2017, 3, 4, 15, 0, 0, 0, time.UTC)
+	first, err := newSpillRecord(spillKindOutput, TimeInstance(ts), EventInfo, ColorLightWhite, NewFields("user_id", 1234), "hello %s", "world")
+	assert.Nil(err)
+	second, err := newSpillRecord(spillKindError, TimeInstance(ts.Add(time.Second)), EventError, ColorRed, Fields(nil), exception.New("this is only a test"))
+	assert.Nil(err)
+	assert.Nil(spill.Append(first))
+	assert.Nil(spill.Append(second))
+	assert.True(spill.HasRecords())
+	assert.Nil(spill.Close())
+
+	// simulate a write torn by a crash.
+	file, err := os.OpenFile(spillPath, os.O_APPEND|os.O_WRONLY, 0666)
+	assert.Nil(err)
+	_, err = file.Write([]byte{0, 0, 1})
+	assert.Nil(err)
+	assert.Nil(file.Close())
+
+	spill, err = openSpillFile(spillPath)
+	assert.Nil(err)
+	defer spill.Close()
+	assert.True(spill.HasRecords())
+
+	var replayed []*spillRecord
+	count, err := spill.Replay(func(record *spillRecord) error {
+		replayed = append(replayed, record)
+		return nil
+	})
+	corruption, isCorruption := err.(*SpillCorruptionError)
+	assert.True(isCorruption)
+	assert.Equal(1, corruption.Lost)
+	assert.Equal(spillPath+spillCorruptSuffix, corruption.Path)
+	assert.Equal(2, count)
+	assert.Len(replayed, 2)
+
+	assert.Equal(EventInfo, replayed[0].Flag)
+	assert.Equal("hello world", replayed[0].Message)
+	assert.False(replayed[0].ErrorOutput)
+	assert.Equal(ts, replayed[0].timeSource().UTCNow())
+	userID, ok := replayed[0].fieldValues().Get("user_id")
+	assert.True(ok)
+	assert.Equal(float64(1234), userID)
+
+	assert.Equal(EventError, replayed[1].Flag)
+	assert.NotNil(replayed[1].Err)
+	assert.Equal("this is only a test", replayed[1].Err.Error())
+
+	assert.False(spill.HasRecords())
+	stat, err := os.Stat(spillPath)
+	assert.Nil(err)
+	assert.Zero(stat.Size())
+
+	corrupt, err := ioutil.ReadFile(spillPath + spillCorruptSuffix)
+	assert.Nil(err)
+	assert.Equal([]byte{0, 0, 1}, corrupt)
+}
+
+func TestSpillFileReplayCorrupt(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+	spillPath := filepath.Join(tempDir, "spill")
+
+	spill, err := openSpillFile(spillPath)
+	assert.Nil(err)
+	record, err := newSpillRecord(spillKindOutput, TimeInstance(time.Now()), EventInfo, ColorLightWhite, Fields(nil), "this is only a test")
+	assert.Nil(err)
+	assert.Nil(spill.Append(record))
+	assert.Nil(spill.Append(record))
+	assert.Nil(spill.Close())
+
+	contents, err := ioutil.ReadFile(spillPath)
+	assert.Nil(err)
+	contents[len(contents)-2] = 'x'
+	assert.Nil(ioutil.WriteFile(spillPath, contents, 0666))
+
+	spill, err = openSpillFile(spillPath)
+	assert.Nil(err)
+	defer spill.Close()
+
+	count, err := spill.Replay(func(record *spillRecord) error { return nil })
+	assert.NotNil(err)
+	assert.Equal(1, count)
+
+	corrupt, err := ioutil.ReadFile(spillPath + spillCorruptSuffix)
+	assert.Nil(err)
+	assert.Equal(len(contents)/2, len(corrupt))
+}
+
+func TestSpillFileReplayOversizedRecord(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+	spillPath := filepath.Join(tempDir, "spill")
+
+	spill, err := openSpillFile(spillPath)
+	assert.Nil(err)
+	record, err := newSpillRecord(spillKindOutput, TimeInstance(time.Now()), EventInfo, ColorLightWhite, Fields(nil), "this is only a test")
+	assert.Nil(err)
+	assert.Nil(spill.Append(record))
+	assert.Nil(spill.Close())
+
+	// a header claiming a huge payload shouldn't be allocated, and leaves the rest of the file unreadable.
+	file, err := os.OpenFile(spillPath, os.O_APPEND|os.O_WRONLY, 0666)
+	assert.Nil(err)
+	_, err = file.Write([]byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0, 'x', 'y'})
+	assert.Nil(err)
+	assert.Nil(file.Close())
+
+	spill, err = openSpillFile(spillPath)
+	assert.Nil(err)
+	defer spill.Close()
+
+	count, err := spill.Replay(func(record *spillRecord) error { return nil })
+	corruption, isCorruption := err.(*SpillCorruptionError)
+	assert.True(isCorruption)
+	assert.Equal(1, corruption.Lost)
+	assert.Equal(1, count)
+
+	corrupt, err := ioutil.ReadFile(spillPath + spillCorruptSuffix)
+	assert.Nil(err)
+	assert.Len(corrupt, 10)
+}
+
+func TestSpillFileReplayKeepsUnreplayed(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+	spillPath := filepath.Join(tempDir, "spill")
+
+	spill, err := openSpillFile(spillPath)
+	assert.Nil(err)
+	defer spill.Close()
+	for _, message := range []string{"first", "second", "third"} {
+		record, err := newSpillRecord(spillKindOutput, TimeInstance(time.Now()), EventInfo, ColorLightWhite, Fields(nil), message)
+		assert.Nil(err)
+		assert.Nil(spill.Append(record))
+	}
+
+	count, err := spill.Replay(func(record *spillRecord) error {
+		if record.Message == "second" {
+			return exception.New("write failed")
+		}
+		return nil
+	})
+	assert.NotNil(err)
+	assert.Equal(1, count)
+	assert.True(spill.HasRecords())
+
+	var replayed []string
+	count, err = spill.Replay(func(record *spillRecord) error {
+		replayed = append(replayed, record.Message)
+		return nil
+	})
+	assert.Nil(err)
+	assert.Equal(2, count)
+	assert.Equal([]string{"second", "third"}, replayed)
+	assert.False(spill.HasRecords())
+
+	_, err = os.Stat(spillPath + spillCorruptSuffix)
+	assert.True(os.IsNotExist(err))
+}
+
+func TestSpillFileAppendDuringReplay(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+	spillPath := filepath.Join(tempDir, "spill")
+
+	spill, err := openSpillFile(spillPath)
+	assert.Nil(err)
+	defer spill.Close()
+	for _, message := range []string{"first", "second"} {
+		record, err := newSpillRecord(spillKindOutput, TimeInstance(time.Now()), EventInfo, ColorLightWhite, Fields(nil), message)
+		assert.Nil(err)
+		assert.Nil(spill.Append(record))
+	}
+
+	// the handler appends while the replay is running, which would block if replaying held the file.
+	var replayed []string
+	count, err := spill.Replay(func(record *spillRecord) error {
+		replayed = append(replayed, record.Message)
+		if record.Message == "first" {
+			spilled, err := newSpillRecord(spillKindOutput, TimeInstance(time.Now()), EventInfo, ColorLightWhite, Fields(nil), "spilled during replay")
+			if err != nil {
+				return err
+			}
+			return spill.Append(spilled)
+		}
+		return nil
+	})
+	assert.Nil(err)
+	assert.Equal(3, count)
+	assert.Equal([]string{"first", "second", "spilled during replay"}, replayed)
+	assert.False(spill.HasRecords())
+
+	_, err = os.Stat(spillPath + spillReplaySuffix)
+	assert.True(os.IsNotExist(err))
+}
+
+func TestSpillFileReplaySegmentLeftOver(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+	spillPath := filepath.Join(tempDir, "spill")
+
+	// records left in a replay segment by a replay that didn't finish are older than the ones in the file.
+	for _, path := range []string{spillPath + spillReplaySuffix, spillPath} {
+		spill, err := openSpillFile(path)
+		assert.Nil(err)
+		record, err := newSpillRecord(spillKindOutput, TimeInstance(time.Now()), EventInfo, ColorLightWhite, Fields(nil), filepath.Base(path))
+		assert.Nil(err)
+		assert.Nil(spill.Append(record))
+		assert.Nil(spill.Close())
+	}
+
+	spill, err := openSpillFile(spillPath)
+	assert.Nil(err)
+	defer spill.Close()
+	assert.True(spill.HasRecords())
+
+	var replayed []string
+	count, err := spill.Replay(func(record *spillRecord) error {
+		replayed = append(replayed, record.Message)
+		return nil
+	})
+	assert.Nil(err)
+	assert.Equal(2, count)
+	assert.Equal([]string{"spill" + spillReplaySuffix, "spill"}, replayed)
+	assert.False(spill.HasRecords())
+}
+
+func TestSpillFileAppendTooLarge(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+
+	spill, err := openSpillFile(filepath.Join(tempDir, "spill"))
+	assert.Nil(err)
+	defer spill.Close()
+
+	record, err := newSpillRecord(spillKindOutput, TimeInstance(time.Now()), EventInfo, ColorLightWhite, Fields(nil), string(make([]byte, maxSpillRecordSize)))
+	assert.Nil(err)
+	assert.NotNil(spill.Append(record))
+	assert.False(spill.HasRecords())
+}
+
+func TestSpillRecordWriteTo(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := bytes.NewBuffer(nil)
+	writer := NewWriter(buffer)
+	writer.SetUseAnsiColors(false)
+
+	ts := time.Date(2017, 3, 4, 15, 0, 0, 0, time.UTC)
+	record, err := newSpillRecord(spillKindOutput, TimeInstance(ts), EventInfo, ColorLightWhite, Fields(nil), "hello %s", "world")
+	assert.Nil(err)
+	assert.Nil(record.writeTo(writer))
+	assert.Contains(buffer.String(), ts.Format(DefaultTimeFormat))
+	assert.Contains(buffer.String(), "[info] hello world")
+}
+
+func TestOverflowQueueSpill(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+
+	spill, err := openSpillFile(filepath.Join(tempDir, "spill"))
+	assert.Nil(err)
+	defer spill.Close()
+
+	bq := newBlockedQueue()
+	defer bq.queue.Close()
+	oq := newOverflowQueue(bq.queue, QueueOverflowSpill, 0)
+	oq.spill = spill
+
+	replayed := make(chan string, 1)
+	oq.spillReplay = func(record *spillRecord) error {
+		replayed <- record.Message
+		return nil
+	}
+
+	bq.finished.Add(3)
+	assert.True(oq.Enqueue(EventInfo, bq.block, "first"))
+	<-bq.started
+	assert.True(oq.Enqueue(EventInfo, bq.record, "second"))
+	assert.True(oq.Enqueue(EventInfo, bq.record, "third"))
+	assert.True(oq.EnqueueWrite(spillKindOutput, EventInfo, bq.record, TimeNow(), EventInfo, ColorLightWhite, Fields(nil), "fourth"))
+	assert.False(oq.Enqueue(EventWebRequest, bq.record, "fifth"))
+	assert.True(spill.HasRecords())
+	close(bq.release)
+	bq.finished.Wait()
+
+	assert.Equal([]string{"first", "second", "third"}, bq.ran)
+	assert.Equal("fourth", <-replayed)
+	assert.Equal(map[EventFlag]int64{EventWebRequest: 1}, oq.Dropped())
+}
+
+func TestAgentEnableSpilloverReplaysOnStart(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+	spillPath := filepath.Join(tempDir, "spill")
+
+	spill, err := openSpillFile(spillPath)
+	assert.Nil(err)
+	record, err := newSpillRecord(spillKindOutput, TimeInstance(time.Now()), EventInfo, ColorLightWhite, Fields(nil), "left over from last time")
+	assert.Nil(err)
+	assert.Nil(spill.Append(record))
+	assert.Nil(spill.Close())
+
+	// a torn record doesn't stop the agent from spilling.
+	file, err := os.OpenFile(spillPath, os.O_APPEND|os.O_WRONLY, 0666)
+	assert.Nil(err)
+	_, err = file.Write([]byte{0, 0, 1})
+	assert.Nil(err)
+	assert.Nil(file.Close())
+
+	buffer := bytes.NewBuffer(nil)
+	writer := NewWriter(buffer)
+	writer.SetShowTimestamp(false)
+	writer.SetUseAnsiColors(false)
+	agent := NewWithWriter(NewEventFlagSet(EventInfo, EventWarning), writer)
+	defer agent.Close()
+
+	assert.Nil(agent.EnableSpillover(spillPath))
+	assert.Equal(QueueOverflowSpill, agent.QueueOverflowPolicy())
+	assert.False(agent.IsEnabled(EventQueueOverflow), "spilling shouldn't change the events")
+	assert.Contains(buffer.String(), "[info] left over from last time")
+	assert.Contains(buffer.String(), "1 spillover record(s) couldn't be read")
+}
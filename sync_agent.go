@@ -1,7 +1,6 @@
 package logger
 
 import (
-	"fmt"
 	"net/http"
 	"os"
 )
@@ -38,12 +37,28 @@ func (sa *SyncAgent) Debugf(format string, args ...interface{}) {
 	sa.WriteEventf(EventDebug, ColorLightYellow, format, args...)
 }
 
+// InfoWithFields logs an informational message with structured fields to the output stream.
+func (sa *SyncAgent) InfoWithFields(fields Fields, format string, args ...interface{}) {
+	if sa == nil {
+		return
+	}
+	sa.WriteEventWithFields(EventInfo, ColorLightWhite, fields, format, args...)
+}
+
+// DebugWithFields logs a debug message with structured fields to the output stream.
+func (sa *SyncAgent) DebugWithFields(fields Fields, format string, args ...interface{}) {
+	if sa == nil {
+		return
+	}
+	sa.WriteEventWithFields(EventDebug, ColorLightYellow, fields, format, args...)
+}
+
 // Warningf logs a debug message to the output stream.
 func (sa *SyncAgent) Warningf(format string, args ...interface{}) error {
 	if sa == nil {
 		return nil
 	}
-	return sa.Warning(fmt.Errorf(format, args...))
+	return sa.Warning(newLazyError(format, args...))
 }
 
 // Warning logs a warning error to std err.
@@ -54,12 +69,20 @@ func (sa *SyncAgent) Warning(err error) error {
 	return sa.ErrorEventWithState(EventWarning, ColorLightYellow, err)
 }
 
-// WarningWithReq logs a warning error to std err with a request.
+// WarningWithReq logs a warning error to std err with a request, and its request id if it has one.
 func (sa *SyncAgent) WarningWithReq(err error, req *http.Request) error {
 	if sa == nil {
 		return err
 	}
-	return sa.ErrorEventWithState(EventWarning, ColorLightYellow, err, req)
+	return sa.ErrorEventWithFields(EventWarning, ColorLightYellow, err, requestIDFields(req), req)
+}
+
+// WarningWithFields logs a warning error with structured fields to std err.
+func (sa *SyncAgent) WarningWithFields(err error, fields Fields) error {
+	if sa == nil {
+		return err
+	}
+	return sa.ErrorEventWithFields(EventWarning, ColorLightYellow, err, fields)
 }
 
 // Errorf writes an event to the log and triggers event listeners.
@@ -67,7 +90,7 @@ func (sa *SyncAgent) Errorf(format string, args ...interface{}) error {
 	if sa == nil {
 		return nil
 	}
-	return sa.Error(fmt.Errorf(format, args...))
+	return sa.Error(newLazyError(format, args...))
 }
 
 // Error logs an error to std err.
@@ -78,12 +101,20 @@ func (sa *SyncAgent) Error(err error) error {
 	return sa.ErrorEventWithState(EventError, ColorRed, err)
 }
 
-// ErrorWithReq logs an error to std err with a request.
+// ErrorWithReq logs an error to std err with a request, and its request id if it has one.
 func (sa *SyncAgent) ErrorWithReq(err error, req *http.Request) error {
 	if sa == nil {
 		return err
 	}
-	return sa.ErrorEventWithState(EventError, ColorRed, err, req)
+	return sa.ErrorEventWithFields(EventError, ColorRed, err, requestIDFields(req), req)
+}
+
+// ErrorWithFields logs an error with structured fields to std err.
+func (sa *SyncAgent) ErrorWithFields(err error, fields Fields) error {
+	if sa == nil {
+		return err
+	}
+	return sa.ErrorEventWithFields(EventError, ColorRed, err, fields)
 }
 
 // Fatalf writes an event to the log and triggers event listeners.
@@ -91,7 +122,7 @@ func (sa *SyncAgent) Fatalf(format string, args ...interface{}) error {
 	if sa == nil {
 		return nil
 	}
-	return sa.Fatal(fmt.Errorf(format, args...))
+	return sa.Fatal(newLazyError(format, args...))
 }
 
 // Fatal logs the result of a panic to std err.
@@ -102,12 +133,20 @@ func (sa *SyncAgent) Fatal(err error) error {
 	return sa.ErrorEventWithState(EventFatalError, ColorRed, err)
 }
 
-// FatalWithReq logs the result of a fatal error to std err with a request.
+// FatalWithReq logs the result of a fatal error to std err with a request, and its request id if it has one.
 func (sa *SyncAgent) FatalWithReq(err error, req *http.Request) error {
 	if sa == nil {
 		return err
 	}
-	return sa.ErrorEventWithState(EventFatalError, ColorRed, err, req)
+	return sa.ErrorEventWithFields(EventFatalError, ColorRed, err, requestIDFields(req), req)
+}
+
+// FatalWithFields logs the result of a fatal error with structured fields to std err.
+func (sa *SyncAgent) FatalWithFields(err error, fields Fields) error {
+	if sa == nil {
+		return err
+	}
+	return sa.ErrorEventWithFields(EventFatalError, ColorRed, err, fields)
 }
 
 // FatalExit logs the result of a fatal error to std err and calls `exit(1)`.
@@ -123,6 +162,14 @@ func (sa *SyncAgent) FatalExit(err error) {
 
 // WriteEventf writes to the standard output and triggers events.
 func (sa *SyncAgent) WriteEventf(event EventFlag, color AnsiColorCode, format string, args ...interface{}) {
+	if sa == nil {
+		return
+	}
+	sa.WriteEventWithFields(event, color, nil, format, args...)
+}
+
+// WriteEventWithFields writes to the standard output with structured fields and triggers events.
+func (sa *SyncAgent) WriteEventWithFields(event EventFlag, color AnsiColorCode, fields Fields, format string, args ...interface{}) {
 	if sa == nil {
 		return
 	}
@@ -130,10 +177,10 @@ func (sa *SyncAgent) WriteEventf(event EventFlag, color AnsiColorCode, format st
 		return
 	}
 	if sa.a.IsEnabled(event) {
-		sa.a.write(append([]interface{}{TimeNow(), event, color, format}, args...)...)
+		sa.a.write(append([]interface{}{TimeNow(), event, color, fields, format}, args...)...)
 
 		if sa.a.HasListener(event) {
-			sa.a.triggerListeners(append([]interface{}{TimeNow(), event, format}, args...)...)
+			sa.a.triggerListeners(appendFields(append([]interface{}{TimeNow(), event, format}, args...), fields)...)
 		}
 	}
 }
@@ -147,7 +194,7 @@ func (sa *SyncAgent) WriteErrorEventf(event EventFlag, color AnsiColorCode, form
 		return
 	}
 	if sa.a.IsEnabled(event) {
-		sa.a.writeError(append([]interface{}{TimeNow(), event, color, format}, args...)...)
+		sa.a.writeError(append([]interface{}{TimeNow(), event, color, Fields(nil), format}, args...)...)
 
 		if sa.a.HasListener(event) {
 			sa.a.triggerListeners(append([]interface{}{TimeNow(), event, format}, args...)...)
@@ -157,6 +204,14 @@ func (sa *SyncAgent) WriteErrorEventf(event EventFlag, color AnsiColorCode, form
 
 // ErrorEventWithState writes an error and triggers events with a given state.
 func (sa *SyncAgent) ErrorEventWithState(event EventFlag, color AnsiColorCode, err error, state ...interface{}) error {
+	if sa == nil {
+		return err
+	}
+	return sa.ErrorEventWithFields(event, color, err, nil, state...)
+}
+
+// ErrorEventWithFields writes an error with structured fields and triggers events with a given state.
+func (sa *SyncAgent) ErrorEventWithFields(event EventFlag, color AnsiColorCode, err error, fields Fields, state ...interface{}) error {
 	if sa == nil {
 		return err
 	}
@@ -165,9 +220,9 @@ func (sa *SyncAgent) ErrorEventWithState(event EventFlag, color AnsiColorCode, e
 	}
 	if err != nil {
 		if sa.a.IsEnabled(event) {
-			sa.a.writeError(TimeNow(), event, color, "%+v", err)
+			sa.a.writeErr(TimeNow(), event, color, fields, err)
 			if sa.a.HasListener(event) {
-				sa.a.triggerListeners(append([]interface{}{TimeNow(), event, err}, state...)...)
+				sa.a.triggerListeners(appendFields(append([]interface{}{TimeNow(), event, err}, state...), fields)...)
 			}
 		}
 	}
@@ -69,6 +69,22 @@ func TestSyncAgentErrorf(t *testing.T) {
 	assert.Equal("[error] this is a test\n", buffer.String())
 }
 
+func TestSyncAgentInfoWithFields(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := bytes.NewBuffer(nil)
+	var fields Fields
+	a := All(NewWriter(buffer))
+	a.Writer().SetShowTimestamp(false)
+	a.Writer().SetUseAnsiColors(false)
+	a.AddEventListener(EventInfo, NewFieldsListener(func(writer *Writer, ts TimeSource, eventFlag EventFlag, eventFields Fields, state ...interface{}) {
+		fields = eventFields
+	}))
+	a.Sync().InfoWithFields(NewFields("user_id", 123, "tenant", "acme"), "this is a %s", "test")
+	assert.Equal(NewFields("user_id", 123, "tenant", "acme"), fields)
+	assert.Equal("[info] this is a test user_id=123 tenant=acme\n", buffer.String())
+}
+
 func TestSyncAgentOnEvent(t *testing.T) {
 	assert := assert.New(t)
 
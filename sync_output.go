@@ -27,6 +27,28 @@ func (so *SyncOutput) Write(buffer []byte) (int, error) {
 	return so.output.Write(buffer)
 }
 
+// WriteRecord implements RecordWriter, serializing access to the inner writer.
+// If the inner writer is not a RecordWriter the record is formatted with the writer's formatter.
+func (so *SyncOutput) WriteRecord(wr *Writer, record *Record) (int64, error) {
+	so.syncRoot.Lock()
+	defer so.syncRoot.Unlock()
+
+	if typed, isTyped := so.output.(RecordWriter); isTyped {
+		return typed.WriteRecord(wr, record)
+	}
+	return formatRecordTo(wr, so.output, wr.Formatter(), record)
+}
+
+// Reopen reopens the inner writer if it is a Reopener.
+func (so *SyncOutput) Reopen() error {
+	if typed, isTyped := so.output.(Reopener); isTyped {
+		so.syncRoot.Lock()
+		defer so.syncRoot.Unlock()
+		return typed.Reopen()
+	}
+	return nil
+}
+
 /* experimental; we cannot close stdout or stderr
 otherwise the program crashes
 // Close is a no-op.
@@ -0,0 +1,107 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// TextFormatter renders records as (optionally colorized) space separated text.
+// It is the default formatter.
+type TextFormatter struct{}
+
+// Format implements Formatter.
+func (tf *TextFormatter) Format(wr *Writer, buf *bytes.Buffer, record *Record) error {
+	if wr.showTimestamp {
+		buf.WriteString(wr.GetTimestamp(record.TimeSource))
+		buf.WriteRune(RuneSpace)
+	}
+
+	// the component of a child agent is always shown, after the writer's label if it is shown.
+	fields := record.Fields
+	var label string
+	if wr.showLabel {
+		label = record.Label
+	}
+	if component, hasComponent := fields.Get(FieldComponent); hasComponent {
+		label = composeLabel(label, fmt.Sprintf("%v", component))
+		fields = fields.without(FieldComponent)
+	}
+	if len(label) > 0 {
+		buf.WriteString(wr.Colorize(label, ColorBlue))
+		buf.WriteRune(RuneSpace)
+	}
+
+	if len(record.Event) == 0 {
+		buf.WriteString(record.Message)
+		return nil
+	}
+
+	buf.WriteString(wr.FormatEvent(record.Event, record.Color))
+	if record.Err != nil {
+		buf.WriteRune(RuneSpace)
+		buf.WriteString(fmt.Sprintf("%+v", record.Err))
+	} else if len(record.Message) > 0 {
+		buf.WriteRune(RuneSpace)
+		buf.WriteString(record.Message)
+	}
+	if len(fields) > 0 {
+		buf.WriteRune(RuneSpace)
+		switch record.Event {
+		case EventWebRequestStart, EventWebRequest:
+			tf.writeRequestFields(wr, buf, fields)
+		default:
+			fields.writeTo(buf)
+		}
+	}
+	return nil
+}
+
+// writeRequestFields writes the fields of web request events as space separated values.
+// Fields that aren't request fields are written as `key=value` pairs.
+func (tf *TextFormatter) writeRequestFields(wr *Writer, buf *bytes.Buffer, fields Fields) {
+	for x, field := range fields {
+		if x > 0 {
+			buf.WriteRune(RuneSpace)
+		}
+		switch field.Key {
+		case requestFieldIP, requestFieldPath:
+			buf.WriteString(fmt.Sprintf("%v", field.Value))
+		case requestFieldMethod:
+			buf.WriteString(wr.Colorize(fmt.Sprintf("%v", field.Value), ColorBlue))
+		case requestFieldStatus:
+			if statusCode, isInt := field.Value.(int); isInt {
+				buf.WriteString(wr.ColorizeByStatusCode(statusCode, strconv.Itoa(statusCode)))
+			} else {
+				buf.WriteString(fmt.Sprintf("%v", field.Value))
+			}
+		case requestFieldElapsed:
+			if elapsed, isFloat := field.Value.(float64); isFloat {
+				buf.WriteString(time.Duration(math.Round(elapsed * float64(time.Millisecond))).String())
+			} else {
+				buf.WriteString(fmt.Sprintf("%v", field.Value))
+			}
+		case requestFieldBytes:
+			if contentLength, isInt := field.Value.(int); isInt {
+				buf.WriteString(File.FormatSize(contentLength))
+			} else {
+				buf.WriteString(fmt.Sprintf("%v", field.Value))
+			}
+		default:
+			Fields{field}.writeTo(buf)
+		}
+	}
+}
+
+// composeLabel returns a label followed by a component, e.g. `api/billing`.
+func composeLabel(label, component string) string {
+	if len(label) == 0 {
+		return component
+	}
+	if len(component) == 0 {
+		return label
+	}
+	return label + "/" + component
+}
@@ -0,0 +1,52 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	assert "github.com/blendlabs/go-assert"
+)
+
+func TestTextFormatterEvent(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := bytes.NewBuffer(nil)
+	writer := NewWriter(buffer)
+	writer.SetShowTimestamp(false)
+	writer.SetUseAnsiColors(false)
+
+	writer.PrintRecord(&Record{Event: EventInfo, Message: "hello", Fields: NewFields("foo", "bar")})
+	writer.PrintRecord(&Record{Event: EventError, Err: fmt.Errorf("only a test")})
+	writer.PrintRecord(&Record{Message: "plain"})
+	assert.Equal("[info] hello foo=bar\n[error] only a test\nplain\n", buffer.String())
+}
+
+func TestTextFormatterRequest(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := bytes.NewBuffer(nil)
+	writer := NewWriter(buffer)
+	writer.SetShowTimestamp(false)
+	writer.SetUseAnsiColors(false)
+
+	req := &http.Request{Method: "GET", URL: &url.URL{Path: "/foo"}, RemoteAddr: "1.2.3.4:5678"}
+	WriteRequestStart(writer, SystemClock, req)
+	WriteRequest(writer, SystemClock, req, http.StatusOK, 2048, 1300*time.Microsecond)
+	assert.Equal("[web.request.start] 1.2.3.4 GET /foo\n[web.request] 1.2.3.4 GET /foo 200 1.3ms 2kb\n", buffer.String())
+}
+
+func TestTextFormatterRequestColorized(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := bytes.NewBuffer(nil)
+	writer := NewWriter(buffer)
+	writer.SetShowTimestamp(false)
+
+	req := &http.Request{Method: "GET", URL: &url.URL{Path: "/foo"}, RemoteAddr: "1.2.3.4:5678"}
+	WriteRequest(writer, SystemClock, req, http.StatusInternalServerError, 512, time.Millisecond)
+	assert.Equal("["+ColorGreen.Apply("web.request")+"] 1.2.3.4 "+ColorBlue.Apply("GET")+" /foo "+ColorRed.Apply("500")+" 1ms 512\n", buffer.String())
+}
@@ -106,6 +106,35 @@ func stateAsBytes(state interface{}) ([]byte, error) {
 	return nil, errTypeConversion
 }
 
+func stateAsError(state interface{}) (error, error) {
+	if typed, isTyped := state.(error); isTyped {
+		return typed, nil
+	}
+	return nil, errTypeConversion
+}
+
+func stateAsFields(state interface{}) (Fields, error) {
+	if state == nil {
+		return nil, nil
+	}
+	if typed, isTyped := state.(Fields); isTyped {
+		return typed, nil
+	}
+	return nil, errTypeConversion
+}
+
+// eventFields are the structured fields appended to an event's state.
+// They have a type of their own, so a `Fields` value passed as the last of an event's args isn't mistaken for them.
+type eventFields Fields
+
+// appendFields appends fields to an event state if any are set.
+func appendFields(state []interface{}, fields Fields) []interface{} {
+	if len(fields) > 0 {
+		return append(state, eventFields(fields))
+	}
+	return state
+}
+
 func envFlagIsSet(flagName string, defaultValue bool) bool {
 	flagValue := os.Getenv(flagName)
 	if len(flagValue) > 0 {
@@ -141,6 +170,18 @@ func envFlagInt64(flagName string, defaultValue int64) int64 {
 	return defaultValue
 }
 
+func envFlagDuration(flagName string, defaultValue time.Duration) time.Duration {
+	flagValue := os.Getenv(flagName)
+	if len(flagValue) > 0 {
+		value, err := time.ParseDuration(flagValue)
+		if err != nil {
+			return defaultValue
+		}
+		return value
+	}
+	return defaultValue
+}
+
 var (
 	// LowerA is the ascii int value for 'a'
 	LowerA = uint('a')
@@ -3,77 +3,94 @@ package logger
 import (
 	"fmt"
 	"net/http"
-	"strconv"
 	"time"
 )
 
+const (
+	requestFieldIP      = "ip"
+	requestFieldMethod  = "method"
+	requestFieldPath    = "path"
+	requestFieldStatus  = "status"
+	requestFieldElapsed = "elapsed_ms"
+	requestFieldBytes   = "bytes"
+)
+
 // WriteEventf is a helper for creating new logging messasges.
 func WriteEventf(writer *Writer, ts TimeSource, event EventFlag, color AnsiColorCode, format string, args ...interface{}) {
-	buffer := writer.GetBuffer()
-	defer writer.PutBuffer(buffer)
-
-	buffer.WriteString(writer.FormatEvent(event, color))
-	buffer.WriteRune(RuneSpace)
-	buffer.WriteString(fmt.Sprintf(format, args...))
-	buffer.WriteRune(RuneSpace)
-
-	writer.WriteWithTimeSource(ts, buffer.Bytes())
+	writer.PrintRecord(&Record{
+		TimeSource: ts,
+		Event:      event,
+		Color:      color,
+		Message:    fmt.Sprintf(format, args...),
+	})
 }
 
 // WriteRequestStart is a helper method to write request start events to a writer.
 func WriteRequestStart(writer *Writer, ts TimeSource, req *http.Request) {
-	buffer := writer.GetBuffer()
-	defer writer.PutBuffer(buffer)
-
-	buffer.WriteString(writer.FormatEvent(EventWebRequestStart, ColorGreen))
-	buffer.WriteRune(RuneSpace)
-	buffer.WriteString(GetIP(req))
-	buffer.WriteRune(RuneSpace)
-	buffer.WriteString(writer.Colorize(req.Method, ColorBlue))
-	buffer.WriteRune(RuneSpace)
-	buffer.WriteString(req.URL.Path)
-
-	writer.WriteWithTimeSource(ts, buffer.Bytes())
+	writer.PrintRecord(NewRequestStartRecord(ts, req))
 }
 
 // WriteRequest is a helper method to write request complete events to a writer.
 func WriteRequest(writer *Writer, ts TimeSource, req *http.Request, statusCode, contentLengthBytes int, elapsed time.Duration) {
-	buffer := writer.GetBuffer()
-	defer writer.PutBuffer(buffer)
+	writer.PrintRecord(NewRequestRecord(ts, req, statusCode, contentLengthBytes, elapsed))
+}
 
-	buffer.WriteString(writer.FormatEvent(EventWebRequest, ColorGreen))
-	buffer.WriteRune(RuneSpace)
-	buffer.WriteString(GetIP(req))
-	buffer.WriteRune(RuneSpace)
-	buffer.WriteString(writer.Colorize(req.Method, ColorBlue))
-	buffer.WriteRune(RuneSpace)
-	buffer.WriteString(req.URL.Path)
-	buffer.WriteRune(RuneSpace)
-	buffer.WriteString(writer.ColorizeByStatusCode(statusCode, strconv.Itoa(statusCode)))
-	buffer.WriteRune(RuneSpace)
-	buffer.WriteString(elapsed.String())
-	buffer.WriteRune(RuneSpace)
-	buffer.WriteString(File.FormatSize(contentLengthBytes))
+// WriteRequestBody is a helper method to write request body events to a writer.
+// Pass the event's fields (from `NewRequestBodyListener`) to write the request id the middleware adds to them.
+func WriteRequestBody(writer *Writer, ts TimeSource, body []byte, fields ...Field) {
+	writer.PrintRecord(&Record{
+		TimeSource: ts,
+		Event:      EventWebRequestPostBody,
+		Color:      ColorGreen,
+		Message:    string(body),
+		Fields:     fields,
+	})
+}
+
+// WriteResponseBody is a helper method to write response body events to a writer.
+// Pass the event's fields (from `NewResponseListener`) to write the request id the middleware adds to them.
+func WriteResponseBody(writer *Writer, ts TimeSource, body []byte, fields ...Field) {
+	writer.PrintRecord(&Record{
+		TimeSource: ts,
+		Event:      EventWebResponse,
+		Color:      ColorGreen,
+		Message:    string(body),
+		Fields:     fields,
+	})
+}
 
-	writer.WriteWithTimeSource(ts, buffer.Bytes())
+// NewRequestStartRecord returns a record for a request start event.
+// The record has the `ip`, `method` and `path` fields set, followed by `request_id` if the request's context carries one.
+func NewRequestStartRecord(ts TimeSource, req *http.Request) *Record {
+	return &Record{
+		TimeSource: ts,
+		Event:      EventWebRequestStart,
+		Color:      ColorGreen,
+		Fields:     append(requestFields(req), requestIDFields(req)...),
+	}
 }
 
-// WriteRequestBody is a helper method to write request start events to a writer.
-func WriteRequestBody(writer *Writer, ts TimeSource, body []byte) {
-	buffer := writer.GetBuffer()
-	defer writer.PutBuffer(buffer)
-	buffer.WriteString("[" + writer.Colorize(string(EventWebRequestPostBody), ColorGreen) + "]")
-	buffer.WriteRune(RuneSpace)
-	buffer.Write(body)
-	writer.WriteWithTimeSource(ts, buffer.Bytes())
+// NewRequestRecord returns a record for a request complete event.
+// The record has the `ip`, `method`, `path`, `status`, `elapsed_ms` and `bytes` fields set, followed by `request_id` if the
+// request's context carries one.
+func NewRequestRecord(ts TimeSource, req *http.Request, statusCode, contentLengthBytes int, elapsed time.Duration) *Record {
+	return &Record{
+		TimeSource: ts,
+		Event:      EventWebRequest,
+		Color:      ColorGreen,
+		Fields: append(append(requestFields(req),
+			Field{Key: requestFieldStatus, Value: statusCode},
+			Field{Key: requestFieldElapsed, Value: Milliseconds(elapsed)},
+			Field{Key: requestFieldBytes, Value: contentLengthBytes},
+		), requestIDFields(req)...),
+	}
 }
 
-// WriteResponseBody is a helper method to write request start events to a writer.
-func WriteResponseBody(writer *Writer, ts TimeSource, body []byte) {
-	buffer := writer.GetBuffer()
-	defer writer.PutBuffer(buffer)
-	buffer.WriteString("[" + writer.Colorize(string(EventWebResponse), ColorGreen) + "]")
-	buffer.WriteRune(RuneSpace)
-	buffer.Write(body)
-	writer.WriteWithTimeSource(ts, buffer.Bytes())
+// requestFields returns the structured fields for a request.
+func requestFields(req *http.Request) Fields {
+	return Fields{
+		{Key: requestFieldIP, Value: GetIP(req)},
+		{Key: requestFieldMethod, Value: req.Method},
+		{Key: requestFieldPath, Value: req.URL.Path},
+	}
 }
@@ -22,6 +22,8 @@ const (
 	DefaultWriterShowTimestamp = true
 	// DefaultWriterShowLabel is a default setting for writers.
 	DefaultWriterShowLabel = false
+	// DefaultWriterOutputFormat is a default setting for writers.
+	DefaultWriterOutputFormat = OutputFormatText
 )
 
 // NewWriter returns a new writer with combined standard and error outputs.
@@ -31,6 +33,7 @@ func NewWriter(output io.Writer) *Writer {
 		useAnsiColors: DefaultWriterUseAnsiColors,
 		showTimestamp: DefaultWriterShowTimestamp,
 		showLabel:     DefaultWriterShowLabel,
+		formatter:     NewFormatter(DefaultWriterOutputFormat),
 		bufferPool:    NewBufferPool(DefaultBufferPoolSize),
 	}
 	return agent
@@ -44,6 +47,7 @@ func NewWriterWithError(output, errorOutput io.Writer) *Writer {
 		useAnsiColors: DefaultWriterUseAnsiColors,
 		showTimestamp: DefaultWriterShowTimestamp,
 		showLabel:     DefaultWriterShowLabel,
+		formatter:     NewFormatter(DefaultWriterOutputFormat),
 		bufferPool:    NewBufferPool(DefaultBufferPoolSize),
 	}
 	return agent
@@ -58,6 +62,7 @@ func NewWriterFromEnvironment() *Writer {
 		showTimestamp: envFlagIsSet(EnvironmentVariableShowTimestamp, DefaultWriterShowTimestamp),
 		showLabel:     envFlagIsSet(EnvironmentVariableShowLabel, DefaultWriterShowLabel),
 		label:         os.Getenv(EnvironmentVariableLogLabel),
+		formatter:     NewFormatter(ParseOutputFormat(os.Getenv(EnvironmentVariableLogFormat))),
 		bufferPool:    NewBufferPool(DefaultBufferPoolSize),
 	}
 }
@@ -74,6 +79,7 @@ func NewWriterToFile(path string) *Writer {
 		showTimestamp: envFlagIsSet(EnvironmentVariableShowTimestamp, DefaultWriterShowTimestamp),
 		showLabel:     envFlagIsSet(EnvironmentVariableShowLabel, DefaultWriterShowLabel),
 		label:         os.Getenv(EnvironmentVariableLogLabel),
+		formatter:     NewFormatter(ParseOutputFormat(os.Getenv(EnvironmentVariableLogFormat))),
 		bufferPool:    NewBufferPool(DefaultBufferPoolSize),
 	}
 }
@@ -97,6 +103,7 @@ func NewWriterToFileWithError(output, errorOutput string) *Writer {
 		showTimestamp: envFlagIsSet(EnvironmentVariableShowTimestamp, DefaultWriterShowTimestamp),
 		showLabel:     envFlagIsSet(EnvironmentVariableShowLabel, DefaultWriterShowLabel),
 		label:         os.Getenv(EnvironmentVariableLogLabel),
+		formatter:     NewFormatter(ParseOutputFormat(os.Getenv(EnvironmentVariableLogFormat))),
 		bufferPool:    NewBufferPool(DefaultBufferPoolSize),
 	}
 }
@@ -112,6 +119,7 @@ type Writer struct {
 
 	timeFormat string
 	label      string
+	formatter  Formatter
 
 	bufferPool *BufferPool
 }
@@ -125,13 +133,28 @@ func (wr *Writer) GetErrorOutput() io.Writer {
 }
 
 // Colorize (optionally) applies a color to a string.
+// Colors are never applied for structured output formats.
 func (wr *Writer) Colorize(value string, color AnsiColorCode) string {
-	if wr.useAnsiColors {
+	if wr.colorsEnabled() {
 		return color.Apply(value)
 	}
 	return value
 }
 
+// colorsEnabled returns if ansi colors should be applied to output.
+func (wr *Writer) colorsEnabled() bool {
+	return wr.useAnsiColors && !wr.isStructured()
+}
+
+// isStructured returns if the writer renders events as something other than text.
+func (wr *Writer) isStructured() bool {
+	if wr.formatter == nil {
+		return false
+	}
+	_, isText := wr.formatter.(*TextFormatter)
+	return !isText
+}
+
 // FormatEvent formats an event label.
 func (wr *Writer) FormatEvent(event EventFlag, color AnsiColorCode) string {
 	return fmt.Sprintf("[%s]", wr.Colorize(string(event), color))
@@ -144,7 +167,7 @@ func (wr *Writer) FormatLabel() string {
 
 // ColorizeByStatusCode colorizes a string by a status code (green, yellow, red).
 func (wr *Writer) ColorizeByStatusCode(statusCode int, value string) string {
-	if wr.useAnsiColors {
+	if wr.colorsEnabled() {
 		if statusCode >= http.StatusOK && statusCode < 300 { //the http 2xx range is ok
 			return ColorGreen.Apply(value)
 		} else if statusCode == http.StatusInternalServerError {
@@ -158,14 +181,19 @@ func (wr *Writer) ColorizeByStatusCode(statusCode int, value string) string {
 
 // GetTimestamp returns a new timestamp string.
 func (wr *Writer) GetTimestamp(optionalTimeSource ...TimeSource) string {
+	if len(optionalTimeSource) > 0 {
+		return wr.Colorize(wr.getTimeFormatted(optionalTimeSource[0]), ColorGray)
+	}
+	return wr.Colorize(wr.getTimeFormatted(SystemClock), ColorGray)
+}
+
+// getTimeFormatted returns the time from a time source formatted with the writer's time format.
+func (wr *Writer) getTimeFormatted(ts TimeSource) string {
 	timeFormat := DefaultTimeFormat
 	if len(wr.timeFormat) > 0 {
 		timeFormat = wr.timeFormat
 	}
-	if len(optionalTimeSource) > 0 {
-		return wr.Colorize(optionalTimeSource[0].UTCNow().Format(timeFormat), ColorGray)
-	}
-	return wr.Colorize(time.Now().UTC().Format(timeFormat), ColorGray)
+	return ts.UTCNow().Format(timeFormat)
 }
 
 // Printf writes to the output stream.
@@ -195,22 +223,7 @@ func (wr *Writer) Write(binary []byte) (int64, error) {
 
 // WriteWithTimeSource writes a binary blob to a given writer, and with a given timing source.
 func (wr *Writer) WriteWithTimeSource(ts TimeSource, binary []byte) (int64, error) {
-	buf := wr.bufferPool.Get()
-	defer wr.bufferPool.Put(buf)
-
-	if wr.showTimestamp {
-		buf.WriteString(wr.GetTimestamp(ts))
-		buf.WriteRune(RuneSpace)
-	}
-
-	if wr.showLabel && len(wr.label) > 0 {
-		buf.WriteString(wr.FormatLabel())
-		buf.WriteRune(RuneSpace)
-	}
-
-	buf.Write(binary)
-	buf.WriteRune(RuneNewline)
-	return buf.WriteTo(wr.Output)
+	return wr.FprintRecord(wr.Output, &Record{TimeSource: ts, Message: string(binary)})
 }
 
 // Fprintf writes a given string and args to a writer.
@@ -220,9 +233,6 @@ func (wr *Writer) Fprintf(w io.Writer, format string, args ...interface{}) (int6
 
 // FprintfWithTimeSource writes a given string and args to a writer and with a given timing source.
 func (wr *Writer) FprintfWithTimeSource(ts TimeSource, w io.Writer, format string, args ...interface{}) (int64, error) {
-	if w == nil {
-		return 0, nil
-	}
 	if len(format) == 0 {
 		return 0, nil
 	}
@@ -230,23 +240,71 @@ func (wr *Writer) FprintfWithTimeSource(ts TimeSource, w io.Writer, format strin
 	if len(message) == 0 {
 		return 0, nil
 	}
+	return wr.FprintRecord(w, &Record{TimeSource: ts, Message: message})
+}
+
+// PrintEventfWithTimeSource writes an event message with structured fields to the output stream, with a given timing source.
+func (wr *Writer) PrintEventfWithTimeSource(ts TimeSource, event EventFlag, color AnsiColorCode, fields Fields, format string, args ...interface{}) (int64, error) {
+	return wr.FprintEventfWithTimeSource(ts, wr.Output, event, color, fields, format, args...)
+}
 
-	buf := wr.bufferPool.Get()
-	defer wr.bufferPool.Put(buf)
+// ErrorEventfWithTimeSource writes an event message with structured fields to the error output stream, with a given timing source.
+func (wr *Writer) ErrorEventfWithTimeSource(ts TimeSource, event EventFlag, color AnsiColorCode, fields Fields, format string, args ...interface{}) (int64, error) {
+	return wr.FprintEventfWithTimeSource(ts, wr.GetErrorOutput(), event, color, fields, format, args...)
+}
 
-	if wr.showTimestamp {
-		buf.WriteString(wr.GetTimestamp(ts))
-		buf.WriteRune(RuneSpace)
+// FprintEventfWithTimeSource writes an event message with structured fields to a writer and with a given timing source.
+func (wr *Writer) FprintEventfWithTimeSource(ts TimeSource, w io.Writer, event EventFlag, color AnsiColorCode, fields Fields, format string, args ...interface{}) (int64, error) {
+	if len(format) == 0 && len(fields) == 0 {
+		return 0, nil
 	}
+	message := fmt.Sprintf(format, args...)
+	if len(message) == 0 && len(fields) == 0 {
+		return 0, nil
+	}
+	return wr.FprintRecord(w, &Record{TimeSource: ts, Event: event, Color: color, Message: message, Fields: fields})
+}
+
+// WriteErrorWithTimeSource writes an error event with structured fields to the error output stream, with a given timing source.
+func (wr *Writer) WriteErrorWithTimeSource(ts TimeSource, event EventFlag, color AnsiColorCode, err error, fields Fields) (int64, error) {
+	return wr.FprintErrorWithTimeSource(ts, wr.GetErrorOutput(), event, color, err, fields)
+}
 
-	if wr.showLabel && len(wr.label) > 0 {
-		buf.WriteString(wr.FormatLabel())
-		buf.WriteRune(RuneSpace)
+// FprintErrorWithTimeSource writes an error event with structured fields to a writer and with a given timing source.
+func (wr *Writer) FprintErrorWithTimeSource(ts TimeSource, w io.Writer, event EventFlag, color AnsiColorCode, err error, fields Fields) (int64, error) {
+	if err == nil {
+		return 0, nil
 	}
+	return wr.FprintRecord(w, &Record{TimeSource: ts, Event: event, Color: color, Err: err, Fields: fields})
+}
+
+// PrintRecord writes a record to the output stream.
+func (wr *Writer) PrintRecord(record *Record) (int64, error) {
+	return wr.FprintRecord(wr.Output, record)
+}
 
-	buf.WriteString(message)
-	buf.WriteRune(RuneNewline)
-	return buf.WriteTo(w)
+// ErrorRecord writes a record to the error output stream.
+func (wr *Writer) ErrorRecord(record *Record) (int64, error) {
+	return wr.FprintRecord(wr.GetErrorOutput(), record)
+}
+
+// FprintRecord formats a record with the writer's formatter and writes it to a given writer.
+// If the writer is a `RecordWriter` the record is handed to it to format and write instead.
+// The record's time source defaults to the system clock and its label to the writer's label.
+func (wr *Writer) FprintRecord(w io.Writer, record *Record) (int64, error) {
+	if w == nil {
+		return 0, nil
+	}
+	if record.TimeSource == nil {
+		record.TimeSource = SystemClock
+	}
+	if len(record.Label) == 0 {
+		record.Label = wr.label
+	}
+	if typed, isTyped := w.(RecordWriter); isTyped {
+		return typed.WriteRecord(wr, record)
+	}
+	return formatRecordTo(wr, w, wr.Formatter(), record)
 }
 
 // UseAnsiColors is a formatting option.
@@ -273,6 +331,36 @@ func (wr *Writer) Label() string { return wr.label }
 // SetLabel sets a formatting option.
 func (wr *Writer) SetLabel(label string) { wr.label = label }
 
+// OutputFormat returns the output format of the writer's formatter.
+// It returns an empty format if a custom formatter is set.
+func (wr *Writer) OutputFormat() OutputFormat {
+	switch wr.Formatter().(type) {
+	case *TextFormatter:
+		return OutputFormatText
+	case *JSONFormatter:
+		return OutputFormatJSON
+	case *LogfmtFormatter:
+		return OutputFormatLogfmt
+	}
+	return OutputFormat(StringEmpty)
+}
+
+// SetOutputFormat sets the formatter to the built in formatter for an output format.
+func (wr *Writer) SetOutputFormat(outputFormat OutputFormat) {
+	wr.formatter = NewFormatter(outputFormat)
+}
+
+// Formatter returns the formatter, defaulting to a `TextFormatter` if one isn't set.
+func (wr *Writer) Formatter() Formatter {
+	if wr.formatter == nil {
+		return &TextFormatter{}
+	}
+	return wr.formatter
+}
+
+// SetFormatter sets the formatter.
+func (wr *Writer) SetFormatter(formatter Formatter) { wr.formatter = formatter }
+
 // TimeFormat is a formatting option.
 func (wr *Writer) TimeFormat() string { return wr.timeFormat }
 
@@ -290,6 +378,24 @@ func (wr *Writer) PutBuffer(buffer *bytes.Buffer) {
 	wr.bufferPool.Put(buffer)
 }
 
+// Reopen reopens the outputs (if they are Reopeners), e.g. file outputs that have been moved by logrotate.
+func (wr *Writer) Reopen() (err error) {
+	var reopenErr error
+	if typed, isTyped := wr.Output.(Reopener); isTyped {
+		reopenErr = typed.Reopen()
+		if reopenErr != nil {
+			err = reopenErr
+		}
+	}
+	if typed, isTyped := wr.ErrorOutput.(Reopener); isTyped {
+		reopenErr = typed.Reopen()
+		if reopenErr != nil {
+			err = reopenErr
+		}
+	}
+	return
+}
+
 // Close closes the writer, free-ing underlying resources.
 func (wr *Writer) Close() (err error) {
 	if wr.Output != nil {
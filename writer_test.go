@@ -70,3 +70,27 @@ func TestWriterErrorOutput(t *testing.T) {
 	assert.Equal(0, stdout.Len())
 	assert.Equal("test string\n", string(stderr.Bytes()))
 }
+
+func TestWriterPrintEventfWithFields(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := bytes.NewBuffer(nil)
+	writer := NewWriter(buffer)
+	writer.showTimestamp = false
+	writer.useAnsiColors = false
+
+	writer.PrintEventfWithTimeSource(SystemClock, EventInfo, ColorWhite, NewFields("user_id", 123, "tenant", "acme"), "test %s", "string")
+	assert.Equal("[info] test string user_id=123 tenant=acme\n", buffer.String())
+}
+
+func TestWriterPrintEventfWithoutFields(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := bytes.NewBuffer(nil)
+	writer := NewWriter(buffer)
+	writer.showTimestamp = false
+	writer.useAnsiColors = false
+
+	writer.PrintEventfWithTimeSource(SystemClock, EventInfo, ColorWhite, nil, "test %s", "string")
+	assert.Equal("[info] test string\n", buffer.String())
+}